@@ -0,0 +1,148 @@
+package fsm
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Clock Time source of FSM timers. Replace it with FakeClock in tests
+	Clock interface {
+		Now() time.Time
+		AfterFunc(d time.Duration, fn func()) Timer
+	}
+
+	// Timer Handle of a function waiting in Clock
+	Timer interface {
+		// Stop prevent the function from running. Return false if it already ran or was stopped
+		Stop() bool
+	}
+
+	// realClock Clock backed by package time
+	realClock struct{}
+
+	// FakeClock Clock that only moves when Advance is called
+	// Due functions run synchronously inside Advance, ordered by deadline
+	FakeClock struct {
+		now    time.Time
+		seq    int
+		timers []*fakeTimer
+		mutex  sync.Mutex
+	}
+
+	// fakeTimer Timer of FakeClock
+	fakeTimer struct {
+		c       *FakeClock
+		at      time.Time
+		seq     int // Keep registration order for same deadline
+		fn      func()
+		stopped bool
+	}
+)
+
+// Ensure interface implement
+var (
+	_ Clock = realClock{}
+	_ Clock = new(FakeClock)
+)
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, fn func()) Timer {
+	return time.AfterFunc(d, fn)
+}
+
+// NewFakeClock new a FakeClock starts at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, fn func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.seq += 1
+	t := &fakeTimer{
+		c:   c,
+		at:  c.now.Add(d),
+		seq: c.seq,
+		fn:  fn,
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance move the clock forward by d and run every function due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	end := c.now.Add(d)
+	c.mutex.Unlock()
+
+	for {
+		c.mutex.Lock()
+		t := c.nextDue(end)
+		if t == nil {
+			c.now = end
+			c.mutex.Unlock()
+			return
+		}
+		c.now = t.at
+		t.stopped = true
+		c.mutex.Unlock()
+
+		// Functions may register or stop timers
+		t.fn()
+	}
+}
+
+// Pending Number of functions waiting
+func (c *FakeClock) Pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.compact()
+	return len(c.timers)
+}
+
+// nextDue Earliest timer not later than end. Caller must hold mutex
+func (c *FakeClock) nextDue(end time.Time) *fakeTimer {
+	c.compact()
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		if c.timers[i].at.Equal(c.timers[j].at) {
+			return c.timers[i].seq < c.timers[j].seq
+		}
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+	if len(c.timers) == 0 || c.timers[0].at.After(end) {
+		return nil
+	}
+	return c.timers[0]
+}
+
+// compact Remove stopped timers. Caller must hold mutex
+func (c *FakeClock) compact() {
+	alive := c.timers[:0]
+	for _, t := range c.timers {
+		if !t.stopped {
+			alive = append(alive, t)
+		}
+	}
+	c.timers = alive
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mutex.Lock()
+	defer t.c.mutex.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
+	return true
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kiexu/go-generic-fsm"
+)
+
+// validate Report load errors and lint issues
+// Dead ends are usually final states, so they are warnings unless -strict is given. Other issues fail the command
+func validate(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	strict := fs.Bool("strict", false, "fail on dead ends too")
+	if err := fs.Parse(args); err != nil {
+		return &usageErr{msg: err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return &usageErr{msg: "validate takes one file"}
+	}
+	d, g, err := load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	issues, err := g.Lint(d.Initial)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, i := range issues {
+		if i.Kind == fsm.LintDeadEnd && !*strict {
+			fmt.Fprintf(stdout, "warning: %v\n", i)
+			continue
+		}
+		fmt.Fprintln(stdout, i)
+		failed += 1
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d issue(s) found", failed)
+	}
+	fmt.Fprintf(stdout, "%s: %d states, %d events, ok\n", fs.Arg(0), len(g.ItoV()), len(d.Events()))
+	return nil
+}
+
+// render Write a diagram of the definition
+func render(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "mermaid", "mermaid, dot or plantuml")
+	if err := fs.Parse(args); err != nil {
+		return &usageErr{msg: err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return &usageErr{msg: "render takes one file"}
+	}
+	_, g, err := load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	opts := &fsm.ExportOpts[string, string, string, fsm.NA]{StoreVal: true}
+	switch *format {
+	case "mermaid":
+		return g.WriteMermaid(stdout, opts)
+	case "dot":
+		return g.WriteDOT(stdout, opts)
+	case "plantuml":
+		return g.WritePlantUML(stdout, opts)
+	}
+	return &usageErr{msg: fmt.Sprintf("unknown format %q", *format)}
+}
+
+// paths Print every path from one state to another, with the events of each step
+func paths(args []string, stdout io.Writer) error {
+	if len(args) != 3 {
+		return &usageErr{msg: "paths takes a file, a from state and a to state"}
+	}
+	_, g, err := load(args[0])
+	if err != nil {
+		return err
+	}
+	from, to := args[1], args[2]
+	for _, s := range []string{from, to} {
+		if g.VertexByState(s) == nil {
+			return &fsm.StateNotExistErr[string]{State: s}
+		}
+	}
+
+	found := g.AllPathTo(from, to)
+	if len(found) == 0 {
+		return fmt.Errorf("no path from %s to %s", from, to)
+	}
+	for _, path := range found {
+		var b strings.Builder
+		b.WriteString(from)
+		prev := g.VertexByState(from)
+		for _, idx := range path {
+			next := g.VertexByIdx(idx)
+			fmt.Fprintf(&b, " --%s--> %s", stepLabel(g, prev, next), next.StateVal())
+			prev = next
+		}
+		fmt.Fprintln(stdout, b.String())
+	}
+	return nil
+}
+
+// stepLabel Events leading from one vertex to another, separated by |. Empty for branches
+func stepLabel(g *fsm.Graph[string, string, string, fsm.NA], from, to *fsm.Vertex[string, fsm.NA]) string {
+	var events []string
+	for _, e := range g.Adj()[from.Idx()].EList() {
+		if e.ToV() == to && !e.Branch() {
+			events = append(events, e.EventVal())
+		}
+	}
+	return strings.Join(events, "|")
+}
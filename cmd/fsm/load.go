@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kiexu/go-generic-fsm"
+	"gopkg.in/yaml.v3"
+)
+
+// load Read a definition and build its graph
+func load(path string) (*fsm.Definition, *fsm.Graph[string, string, string, fsm.NA], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// YAML is converted to JSON, so that both formats share field names and checks
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	d, err := fsm.LoadDefinition(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := d.NewG()
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, g, nil
+}
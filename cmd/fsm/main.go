@@ -0,0 +1,72 @@
+// Command fsm validates, renders and simulates JSON or YAML machine definitions
+//
+// Usage:
+//
+//	fsm validate [-strict] <file>
+//	fsm render [-format mermaid|dot|plantuml] <file>
+//	fsm paths <file> <from> <to>
+//	fsm simulate <file>
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage:
+  fsm validate [-strict] <file>                        Load the definition and lint its graph. -strict fails on dead ends
+  fsm render [-format mermaid|dot|plantuml] <file>     Write a diagram to stdout
+  fsm paths <file> <from> <to>                         List every path between two states
+  fsm simulate <file>                                  Fire events interactively
+Files ending in .yaml or .yml are read as YAML, others as JSON
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run Execute one command, return exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "validate":
+		err = validate(args[1:], stdout)
+	case "render":
+		err = render(args[1:], stdout)
+	case "paths":
+		err = paths(args[1:], stdout)
+	case "simulate":
+		err = simulate(args[1:], stdin, stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		err = &usageErr{msg: fmt.Sprintf("unknown command %q", args[0])}
+	}
+
+	switch err.(type) {
+	case nil:
+		return 0
+	case *usageErr:
+		fmt.Fprintf(stderr, "fsm: %v\n%s", err, usage)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "fsm: %v\n", err)
+		return 1
+	}
+}
+
+// usageErr Wrong arguments
+type usageErr struct {
+	msg string
+}
+
+func (e usageErr) Error() string {
+	return e.msg
+}
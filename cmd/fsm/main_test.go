@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRun(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout []string // Substrings expected in stdout
+		stderr string   // Substring expected in stderr
+	}{
+		{
+			name:   "validate ok",
+			args:   []string{"validate", "../../demo/order.json"},
+			stdout: []string{"4 states, 4 events, ok"},
+		},
+		{
+			name:   "validate issues",
+			args:   []string{"validate", "testdata/broken.json"},
+			code:   1,
+			stdout: []string{"warning: state b has no outgoing transition", "state c is unreachable"},
+			stderr: "1 issue(s) found",
+		},
+		{
+			name:   "validate final state",
+			args:   []string{"validate", "testdata/terminal.json"},
+			stdout: []string{"warning: state done has no outgoing transition", "2 states, 1 events, ok"},
+		},
+		{
+			name:   "validate final state strict",
+			args:   []string{"validate", "-strict", "testdata/terminal.json"},
+			code:   1,
+			stdout: []string{"state done has no outgoing transition"},
+			stderr: "1 issue(s) found",
+		},
+		{
+			name:   "render yaml",
+			args:   []string{"render", "-format", "plantuml", "testdata/order.yaml"},
+			stdout: []string{"@startuml", "s0 --> s3 : abort after 30m0s", "@enduml"},
+		},
+		{
+			name:   "render unknown format",
+			args:   []string{"render", "-format", "svg", "testdata/order.yaml"},
+			code:   2,
+			stderr: `unknown format "svg"`,
+		},
+		{
+			name:   "paths",
+			args:   []string{"paths", "testdata/order.yaml", "initial", "done"},
+			stdout: []string{"initial --pay--> paid --deliver--> done\n"},
+		},
+		{
+			name:   "paths unknown state",
+			args:   []string{"paths", "testdata/order.yaml", "initial", "lost"},
+			code:   1,
+			stderr: "state lost does not exist",
+		},
+		{
+			name:  "simulate",
+			args:  []string{"simulate", "testdata/order.yaml"},
+			stdin: "pay\nnote hi\nbogus\n:wait 30m\n:reset\n:quit\n",
+			stdout: []string{
+				"state: initial\nevents: pay\n",
+				"pay: initial -> paid\nstate: paid\nevents: deliver, abort, note\n",
+				"note: internal in paid\n",
+				"error: event bogus inappropriate in current state paid\n",
+				"abort: paid -> canceled\nstate: canceled\nevents: ready\n",
+			},
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"draw"},
+			code:   2,
+			stderr: "Usage:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			assert.Equal(t, code, tt.code, stderr.String())
+			for _, want := range tt.stdout {
+				assert.Assert(t, strings.Contains(stdout.String(), want), stdout.String())
+			}
+			assert.Assert(t, strings.Contains(stderr.String(), tt.stderr), stderr.String())
+		})
+	}
+}
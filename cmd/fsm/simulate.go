@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kiexu/go-generic-fsm"
+)
+
+const simulateHelp = `  <event> [args...]   Fire an event
+  :wait <duration>    Let time pass, e.g. :wait 30s. State timeouts fire on the way
+  :reset              Back to the initial state
+  :help               Show this help
+  :quit               Leave, same as end of input
+`
+
+// simulate Read commands line by line and fire them against an in-memory FSM
+func simulate(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) != 1 {
+		return &usageErr{msg: "simulate takes one file"}
+	}
+	d, g, err := load(args[0])
+	if err != nil {
+		return err
+	}
+
+	var f *fsm.FSM[string, string, string, fsm.NA]
+	var clock *fsm.FakeClock
+	reset := func() {
+		// New clock as well, so that timers of the old FSM never fire
+		clock = fsm.NewFakeClock(time.Unix(0, 0).UTC())
+		f = fsm.NewFsmByGWithClock(g, d.Initial, clock)
+		f.SetOnTimerErr(func(err error) {
+			fmt.Fprintf(stdout, "timer: %v\n", err)
+		})
+		// Hooks run synchronously, so transitions fired by :wait are printed in order
+		f.AddAfterStateChange(func(e *fsm.Event[string, string, string, fsm.NA]) error {
+			fmt.Fprintf(stdout, "%s: %s -> %s\n", e.EventVal(), e.FromState(), e.ToState())
+			return nil
+		}, 0)
+		f.AddOnInternal(func(e *fsm.Event[string, string, string, fsm.NA]) error {
+			fmt.Fprintf(stdout, "%s: internal in %s\n", e.EventVal(), e.FromState())
+			return nil
+		}, 0)
+	}
+	reset()
+
+	fmt.Fprintf(stdout, "Simulating %s. Type :help for commands\n", args[0])
+	printState(stdout, f)
+	scanner := bufio.NewScanner(stdin)
+	for fmt.Fprint(stdout, "> "); scanner.Scan(); fmt.Fprint(stdout, "> ") {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case ":quit", ":q":
+			return nil
+		case ":help":
+			fmt.Fprint(stdout, simulateHelp)
+			continue
+		case ":reset":
+			reset()
+		case ":wait":
+			if len(fields) != 2 {
+				fmt.Fprintln(stdout, "error: :wait takes one duration")
+				continue
+			}
+			dur, err := time.ParseDuration(fields[1])
+			if err != nil {
+				fmt.Fprintf(stdout, "error: %v\n", err)
+				continue
+			}
+			clock.Advance(dur)
+		default:
+			args := make([]interface{}, 0, len(fields)-1)
+			for _, a := range fields[1:] {
+				args = append(args, a)
+			}
+			e, err := f.Trigger(fields[0], args...)
+			if err != nil {
+				fmt.Fprintf(stdout, "error: %v\n", err)
+				continue
+			}
+			if e.Deferred() {
+				fmt.Fprintf(stdout, "%s: deferred\n", fields[0])
+			}
+		}
+		printState(stdout, f)
+	}
+	fmt.Fprintln(stdout)
+	return scanner.Err()
+}
+
+// printState Show where the FSM is and what it accepts
+func printState(w io.Writer, f *fsm.FSM[string, string, string, fsm.NA]) {
+	snap := f.State()
+	fmt.Fprintf(w, "state: %s", snap.Curr)
+	if len(snap.Regions) > 0 {
+		fmt.Fprintf(w, " regions: [%s]", strings.Join(snap.Regions, ", "))
+	}
+	if len(snap.Stack) > 0 {
+		fmt.Fprintf(w, " stack: [%s]", strings.Join(snap.Stack, ", "))
+	}
+	if deferred := f.DeferredEvents(); len(deferred) > 0 {
+		fmt.Fprintf(w, " deferred: [%s]", strings.Join(deferred, ", "))
+	}
+	fmt.Fprintf(w, "\nevents: %s\n", strings.Join(f.AvailableEvents(), ", "))
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kiexu/go-generic-fsm"
+)
+
+// names Go identifiers of one machine
+type names struct {
+	machine string            // Type of the machine. e.g. Order
+	state   string            // Type of states. e.g. OrderState
+	event   string            // Type of events. e.g. OrderEvent
+	states  map[string]string // State value -> constant
+	events  map[string]string // Event value -> constant
+	methods map[string]string // Event value -> method of machine
+}
+
+// generate Go source of d in package pkg
+func generate(d *fsm.Definition, pkg string) ([]byte, error) {
+	g, err := d.NewG()
+	if err != nil {
+		return nil, err
+	}
+	fac, err := d.DefConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []string
+	for _, v := range g.ItoV() {
+		states = append(states, v.StateVal())
+	}
+	events := d.Events()
+	n, err := newNames(d.Name, states, events)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteByte('\n')
+	}
+	useTime := false
+	for _, t := range d.Transitions {
+		if t.After != "" {
+			useTime = true
+		}
+	}
+
+	p("// Code generated by fsmgen. DO NOT EDIT.")
+	p("")
+	p("package %s", pkg)
+	p("")
+	p("import (")
+	p("\t\"strconv\"")
+	if useTime {
+		p("\t\"time\"")
+	}
+	p("")
+	p("\t\"github.com/kiexu/go-generic-fsm\"")
+	p(")")
+	p("")
+
+	enum := func(typ string, values []string, consts map[string]string) {
+		p("// %s %s of %s", typ, strings.TrimPrefix(typ, n.machine), n.machine)
+		p("type %s int", typ)
+		p("")
+		p("const (")
+		for i, v := range values {
+			if i == 0 {
+				p("\t%s %s = iota", consts[v], typ)
+				continue
+			}
+			p("\t%s", consts[v])
+		}
+		p(")")
+		p("")
+		lower := strings.ToLower(typ[:1]) + typ[1:]
+		p("var %sNames = [...]string{", lower)
+		for _, v := range values {
+			p("\t%s,", strconv.Quote(v))
+		}
+		p("}")
+		p("")
+		p("func (v %s) String() string {", typ)
+		p("\tif v < 0 || int(v) >= len(%sNames) {", lower)
+		p("\t\treturn \"%s(\" + strconv.Itoa(int(v)) + \")\"", typ)
+		p("\t}")
+		p("\treturn %sNames[v]", lower)
+		p("}")
+		p("")
+	}
+	enum(n.state, states, n.states)
+	enum(n.event, events, n.events)
+
+	stateList := func(list []string) string {
+		consts := make([]string, 0, len(list))
+		for _, s := range list {
+			consts = append(consts, n.states[s])
+		}
+		return fmt.Sprintf("[]%s{%s}", n.state, strings.Join(consts, ", "))
+	}
+	typeArgs := fmt.Sprintf("%s, %s, string, fsm.NA", n.state, n.event)
+
+	p("// New%sConfig Config of %s", n.machine, n.machine)
+	p("func New%sConfig() *fsm.DefConfig[%s] {", n.machine, typeArgs)
+	p("\treturn &fsm.DefConfig[%s]{", typeArgs)
+	p("\t\tDescList: []*fsm.DescCell[%s]{", typeArgs)
+	for _, cell := range fac.DescList[len(d.States):] {
+		p("\t\t\t{")
+		p("\t\t\t\tEventVal: %s,", n.events[cell.EventVal])
+		if len(cell.FromState) > 0 {
+			p("\t\t\t\tFromState: %s,", stateList(cell.FromState))
+		}
+		if cell.FromAny {
+			p("\t\t\t\tFromAny: true,")
+		}
+		if len(cell.Except) > 0 {
+			p("\t\t\t\tExcept: %s,", stateList(cell.Except))
+		}
+		if cell.Stack != fsm.StackPop {
+			p("\t\t\t\tToState: %s,", n.states[cell.ToState])
+		}
+		if cell.Internal {
+			p("\t\t\t\tInternal: true,")
+		}
+		switch cell.Stack {
+		case fsm.StackPush:
+			p("\t\t\t\tStack: fsm.StackPush,")
+		case fsm.StackPop:
+			p("\t\t\t\tStack: fsm.StackPop,")
+		}
+		if cell.EventStoreVal != "" {
+			p("\t\t\t\tEventStoreVal: %s,", strconv.Quote(cell.EventStoreVal))
+		}
+		if cell.After > 0 {
+			p("\t\t\t\tAfter: %s,", durationLiteral(cell.After))
+		}
+		p("\t\t\t},")
+	}
+	p("\t\t},")
+	if len(d.Defer) > 0 {
+		p("\t\tDeferMap: map[%s][]%s{", n.state, n.event)
+		for _, s := range states {
+			evs, ok := d.Defer[s]
+			if !ok {
+				continue
+			}
+			consts := make([]string, 0, len(evs))
+			for _, ev := range evs {
+				c, ok := n.events[ev]
+				if !ok {
+					return nil, fmt.Errorf("deferred event %s of state %s has no transition", ev, s)
+				}
+				consts = append(consts, c)
+			}
+			p("\t\t\t%s: {%s},", n.states[s], strings.Join(consts, ", "))
+		}
+		p("\t\t},")
+	}
+	p("\t}")
+	p("}")
+	p("")
+
+	p("// %s FSM with one method per event", n.machine)
+	p("type %s struct {", n.machine)
+	p("\t*fsm.FSM[%s]", typeArgs)
+	p("}")
+	p("")
+	p("// New%s new a %s in %s", n.machine, n.machine, d.Initial)
+	p("func New%s() (*%s, error) {", n.machine, n.machine)
+	p("\treturn New%sAt(%s)", n.machine, n.states[d.Initial])
+	p("}")
+	p("")
+	p("// New%sAt new a %s in given state", n.machine, n.machine)
+	p("func New%sAt(initState %s) (*%s, error) {", n.machine, n.state, n.machine)
+	p("\tf, err := fsm.NewFsm[%s](New%sConfig(), initState)", typeArgs, n.machine)
+	p("\tif err != nil {")
+	p("\t\treturn nil, err")
+	p("\t}")
+	p("\treturn &%s{FSM: f}, nil", n.machine)
+	p("}")
+	for _, ev := range events {
+		p("")
+		p("// %s trigger %s", n.methods[ev], ev)
+		p("func (m *%s) %s(args ...interface{}) (*fsm.Event[%s], error) {", n.machine, n.methods[ev], typeArgs)
+		p("\treturn m.Trigger(%s, args...)", n.events[ev])
+		p("}")
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// newNames Derive identifiers, reject collisions
+func newNames(name string, states, events []string) (*names, error) {
+	machine, err := identifier(name)
+	if err != nil {
+		return nil, fmt.Errorf("name: %w", err)
+	}
+	n := &names{
+		machine: machine,
+		state:   machine + "State",
+		event:   machine + "Event",
+		states:  make(map[string]string),
+		events:  make(map[string]string),
+		methods: make(map[string]string),
+	}
+
+	// Methods promoted from the embedded FSM can not be shadowed
+	reserved := make(map[string]string)
+	t := reflect.TypeOf(&fsm.FSM[string, string, string, fsm.NA]{})
+	for i := 0; i < t.NumMethod(); i += 1 {
+		reserved[t.Method(i).Name] = "method of fsm.FSM"
+	}
+
+	declare := func(ident, owner string) error {
+		if other, ok := reserved[ident]; ok {
+			return fmt.Errorf("%s collides with %s as %s", owner, other, ident)
+		}
+		reserved[ident] = owner
+		return nil
+	}
+	for _, s := range states {
+		id, err := identifier(s)
+		if err != nil {
+			return nil, fmt.Errorf("state %q: %w", s, err)
+		}
+		n.states[s] = n.state + id
+		if err := declare(n.states[s], "state "+s); err != nil {
+			return nil, err
+		}
+	}
+	for _, ev := range events {
+		id, err := identifier(ev)
+		if err != nil {
+			return nil, fmt.Errorf("event %q: %w", ev, err)
+		}
+		n.events[ev] = n.event + id
+		n.methods[ev] = id
+		if err := declare(n.events[ev], "event "+ev); err != nil {
+			return nil, err
+		}
+		if err := declare(id, "method of event "+ev); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// identifier Exported Go identifier of s. Words split by any non letter or digit are capitalized
+// e.g. pay_event, pay-event and payEvent all become PayEvent
+func identifier(s string) (string, error) {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if id == "" {
+		return "", fmt.Errorf("no letter or digit")
+	}
+	if unicode.IsDigit([]rune(id)[0]) {
+		return "", fmt.Errorf("starts with a digit")
+	}
+	return id, nil
+}
+
+// durationLiteral Go expression of d. e.g. 30 * time.Second
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("%d", d)
+}
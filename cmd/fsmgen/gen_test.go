@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kiexu/go-generic-fsm"
+	"gotest.tools/v3/assert"
+)
+
+func TestIdentifier(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "payEvent", want: "PayEvent"},
+		{in: "pay_event", want: "PayEvent"},
+		{in: "in-progress", want: "InProgress"},
+		{in: "état", want: "État"},
+		{in: "2fa", err: true},
+		{in: "--", err: true},
+	}
+	for _, tt := range tests {
+		got, err := identifier(tt.in)
+		if tt.err {
+			assert.Assert(t, err != nil, tt.in)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, got, tt.want)
+	}
+}
+
+func TestGenerate_Demo(t *testing.T) {
+
+	// Generated demo must be up to date
+	f, err := os.Open("../../demo/order.json")
+	assert.NilError(t, err)
+	defer f.Close()
+	d, err := fsm.LoadDefinition(f)
+	assert.NilError(t, err)
+
+	got, err := generate(d, "demo")
+	assert.NilError(t, err)
+	want, err := os.ReadFile("../../demo/order_fsm.go")
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), string(want))
+}
+
+func TestGenerate_Collision(t *testing.T) {
+
+	tests := []struct {
+		name string
+		def  string
+		want string
+	}{
+		{
+			name: "fsm method",
+			def:  `{"name": "door", "initial": "open", "transitions": [{"event": "trigger", "from": ["open"], "to": "shut"}]}`,
+			want: "collides with method of fsm.FSM as Trigger",
+		},
+		{
+			name: "same identifier",
+			def:  `{"name": "door", "initial": "open", "transitions": [{"event": "lock-it", "from": ["open"], "to": "shut"}, {"event": "lock_it", "from": ["shut"], "to": "open"}]}`,
+			want: "event lock_it collides with event lock-it",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := fsm.LoadDefinition(strings.NewReader(tt.def))
+			assert.NilError(t, err)
+			_, err = generate(d, "door")
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Command fsmgen generates typed states, events, config and per-event methods from a JSON machine definition
+//
+// Usage with go generate:
+//
+//	//go:generate go run github.com/kiexu/go-generic-fsm/cmd/fsmgen -in order.json
+//
+// Output goes to <in>_fsm.go next to the input unless -out is set. Package defaults to $GOPACKAGE
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kiexu/go-generic-fsm"
+)
+
+func main() {
+	in := flag.String("in", "", "JSON machine definition. Required")
+	out := flag.String("out", "", "Output file. Default <in without .json>_fsm.go, - for stdout")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "Package of generated code. Default $GOPACKAGE")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "fsmgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	if in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if pkg == "" {
+		return fmt.Errorf("-pkg is required outside go generate")
+	}
+	if out == "" {
+		out = strings.TrimSuffix(in, ".json") + "_fsm.go"
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d, err := fsm.LoadDefinition(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+
+	src, err := generate(d, pkg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
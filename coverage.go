@@ -0,0 +1,204 @@
+package fsm
+
+import (
+	"sync"
+)
+
+type (
+	// Coverage Record vertices and edges exercised by attached FSMs
+	// Hits are keyed by vertex idx and (from idx, event, to idx), so FSMs and coverages of graphs built from
+	// the same config can be mixed even if each has its own Graph
+	Coverage[T, S comparable, U, V any] struct {
+		g        *Graph[T, S, U, V]
+		vertices map[int]int
+		edges    map[edgeKey[S]]int
+		mutex    sync.Mutex
+	}
+
+	// CoverageReport Snapshot of a Coverage
+	CoverageReport[T, S comparable, U, V any] struct {
+		Vertices        int                 // Number of vertices
+		CoveredVertices int                 // Vertices entered at least once
+		Edges           int                 // Number of edges, including branches of pseudo-states
+		CoveredEdges    int                 // Edges taken at least once
+		UncoveredStates []T                 // In idx order
+		UncoveredEdges  []*Edge[T, S, U, V] // In Graph.Adj() order
+	}
+
+	// edgeKey Identify an edge across graphs built from the same config
+	edgeKey[S comparable] struct {
+		from  int
+		event S
+		to    int
+	}
+)
+
+// NewCoverage new an empty Coverage of g
+func NewCoverage[T, S comparable, U, V any](g *Graph[T, S, U, V]) *Coverage[T, S, U, V] {
+	return &Coverage[T, S, U, V]{
+		g:        g,
+		vertices: make(map[int]int),
+		edges:    make(map[edgeKey[S]]int),
+	}
+}
+
+// Attach start recording f. Its current state counts as covered. Remove the handle to stop
+func (c *Coverage[T, S, U, V]) Attach(f *FSM[T, S, U, V]) *HookHandle {
+	if v := f.g.VertexByState(f.CurrState()); v != nil {
+		c.hitVertex(f.g, v)
+	}
+	after := f.AddAfterStateChange(func(e *Event[T, S, U, V]) error {
+		c.record(f.g, e)
+		return nil
+	}, 0)
+	internal := f.AddOnInternal(func(e *Event[T, S, U, V]) error {
+		c.record(f.g, e)
+		return nil
+	}, 0)
+	return &HookHandle{remove: func() {
+		after.Remove()
+		internal.Remove()
+	}}
+}
+
+// Merge add hits of others
+func (c *Coverage[T, S, U, V]) Merge(others ...*Coverage[T, S, U, V]) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, o := range others {
+		if o == c {
+			continue
+		}
+		o.mutex.Lock()
+		for k, n := range o.vertices {
+			c.vertices[k] += n
+		}
+		for k, n := range o.edges {
+			c.edges[k] += n
+		}
+		o.mutex.Unlock()
+	}
+}
+
+// Hits Times e was taken
+func (c *Coverage[T, S, U, V]) Hits(e *Edge[T, S, U, V]) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.edges[keyOf(e)]
+}
+
+// VertexHits Times v was entered
+func (c *Coverage[T, S, U, V]) VertexHits(v *Vertex[T, V]) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.vertices[v.idx]
+}
+
+// Report Count covered vertices and edges of the graph
+func (c *Coverage[T, S, U, V]) Report() *CoverageReport[T, S, U, V] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	r := &CoverageReport[T, S, U, V]{Vertices: len(c.g.itoV)}
+	for _, v := range c.g.itoV {
+		if c.vertices[v.idx] > 0 {
+			r.CoveredVertices += 1
+		} else {
+			r.UncoveredStates = append(r.UncoveredStates, v.stateVal)
+		}
+	}
+	for _, ec := range c.g.adj {
+		if ec == nil {
+			continue
+		}
+		for _, e := range ec.eList {
+			r.Edges += 1
+			if c.edges[keyOf(e)] > 0 {
+				r.CoveredEdges += 1
+			} else {
+				r.UncoveredEdges = append(r.UncoveredEdges, e)
+			}
+		}
+	}
+	return r
+}
+
+// record Count eventE, branches taken after it, and what was entered
+func (c *Coverage[T, S, U, V]) record(g *Graph[T, S, U, V], e *Event[T, S, U, V]) {
+	if e.eventE == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.edges[keyOf(e.eventE)] += 1
+	for _, b := range e.branches {
+		c.vertices[b.fromV.idx] += 1
+		if b.toV.kind == VertexJoin {
+			c.join(g, b.toV)
+			continue
+		}
+		c.edges[keyOf(b)] += 1
+	}
+	// Internal transitions never leave, so nothing is entered
+	if !e.eventE.internal {
+		c.enter(g, e.ToV())
+	}
+}
+
+// join Count the branch of every region into join v. Caller must hold mutex
+func (c *Coverage[T, S, U, V]) join(g *Graph[T, S, U, V], v *Vertex[T, V]) {
+	for _, j := range g.joins {
+		if j.v != v {
+			continue
+		}
+		for _, sv := range j.sources {
+			for _, b := range g.adj[sv.idx].eList {
+				if b.branch && b.toV == v {
+					c.edges[keyOf(b)] += 1
+				}
+			}
+		}
+	}
+}
+
+// hitVertex Count v as entered
+func (c *Coverage[T, S, U, V]) hitVertex(g *Graph[T, S, U, V], v *Vertex[T, V]) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.enter(g, v)
+}
+
+// enter Count v, and the regions started if v is a fork. Caller must hold mutex
+func (c *Coverage[T, S, U, V]) enter(g *Graph[T, S, U, V], v *Vertex[T, V]) {
+	c.vertices[v.idx] += 1
+	if v.kind != VertexFork || g.adj[v.idx] == nil {
+		return
+	}
+	for _, b := range g.adj[v.idx].eList {
+		if b.branch {
+			c.edges[keyOf(b)] += 1
+			c.vertices[b.toV.idx] += 1
+		}
+	}
+}
+
+// Percent Covered edges in percent. 100 if there is no edge
+func (r *CoverageReport[T, S, U, V]) Percent() float64 {
+	if r.Edges == 0 {
+		return 100
+	}
+	return float64(r.CoveredEdges) * 100 / float64(r.Edges)
+}
+
+// VertexPercent Covered vertices in percent. 100 if there is no vertex
+func (r *CoverageReport[T, S, U, V]) VertexPercent() float64 {
+	if r.Vertices == 0 {
+		return 100
+	}
+	return float64(r.CoveredVertices) * 100 / float64(r.Vertices)
+}
+
+// keyOf Key of e
+func keyOf[T, S comparable, U, V any](e *Edge[T, S, U, V]) edgeKey[S] {
+	return edgeKey[S]{from: e.fromV.idx, event: e.eventVal, to: e.toV.idx}
+}
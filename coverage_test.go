@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+
+	g, _ := descFac.NewG()
+	cov := NewCoverage(g)
+
+	// Two FSMs on the same graph
+	first := NewFsmByG(g, initial)
+	cov.Attach(first)
+	for _, ev := range []eventVal{payEvent, deliverEvent, receiveEvent} {
+		_, err := first.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	second := NewFsmByG(g, initial)
+	handle := cov.Attach(second)
+	_, _ = second.Trigger(payEvent)
+
+	r := cov.Report()
+	assert.Equal(t, r.Vertices, 5)
+	assert.Equal(t, r.CoveredVertices, 4)
+	assert.DeepEqual(t, r.UncoveredStates, []nodeState{canceled})
+	assert.Equal(t, r.CoveredEdges, 3)
+	assert.Equal(t, len(r.UncoveredEdges), r.Edges-3)
+	payEdge, _ := g.NextEdge(initial, payEvent)
+	assert.Equal(t, cov.Hits(payEdge), 2)
+
+	// Detached FSMs are not recorded
+	handle.Remove()
+	_, _ = second.Trigger(cancelEvent)
+	assert.Equal(t, cov.Report().CoveredEdges, 3)
+
+	// Coverage of another test, on a graph built from the same config
+	g2, _ := descFac.NewG()
+	other := NewCoverage(g2)
+	third := NewFsmByG(g2, paid)
+	other.Attach(third)
+	_, _ = third.Trigger(cancelEvent)
+
+	cov.Merge(other)
+	r = cov.Report()
+	assert.Equal(t, r.CoveredVertices, 5)
+	assert.Equal(t, r.VertexPercent(), float64(100))
+	assert.Equal(t, r.CoveredEdges, 4)
+	assert.Equal(t, cov.Hits(payEdge), 2)
+	assert.Assert(t, r.Percent() < 100)
+}
+
+func TestCoverage_Pseudo(t *testing.T) {
+
+	testFSM, _ := NewFsm[string, string, NA, NA](approvalFac, "draft")
+	cov := NewCoverage(testFSM.G())
+	cov.Attach(testFSM)
+	for _, ev := range []string{"submit", "legalOK", "financeOK"} {
+		_, err := testFSM.Trigger(ev)
+		assert.NilError(t, err)
+	}
+
+	// Only reject is left, fork branches and join are covered
+	r := cov.Report()
+	assert.Equal(t, r.CoveredVertices, r.Vertices)
+	assert.Equal(t, len(r.UncoveredEdges), 1)
+	assert.Equal(t, r.UncoveredEdges[0].EventVal(), "reject")
+}
+
+func TestCoverage_Internal(t *testing.T) {
+
+	g, _ := (&DefConfig[string, string, NA, NA]{
+		DescList: []*DescCell[string, string, NA, NA]{
+			{EventVal: "start", FromState: []string{"idle"}, ToState: "running"},
+			{EventVal: "tick", FromState: []string{"running"}, ToState: "running", Internal: true},
+		},
+	}).NewG()
+	cov := NewCoverage(g)
+	f := NewFsmByG(g, "idle")
+	cov.Attach(f)
+	for _, ev := range []string{"start", "tick", "tick"} {
+		_, err := f.Trigger(ev)
+		assert.NilError(t, err)
+	}
+
+	tick, _ := g.NextEdge("running", "tick")
+	assert.Equal(t, cov.Hits(tick), 2)
+	assert.Equal(t, cov.VertexHits(g.VertexByState("running")), 1)
+}
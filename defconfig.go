@@ -1,24 +1,58 @@
 package fsm
 
 import (
+	"fmt"
 	"github.com/kiexu/go-generic-collection"
 	"github.com/kiexu/go-generic-collection/hashset"
+	"sort"
+	"time"
 )
 
 type (
 	// DefConfig Default factory with basic config struct
 	// As a regular FSM, {stateVal, eventVal} need to be unique
 	DefConfig[T, S comparable, U, V any] struct {
-		DescList     []*DescCell[T, S, U, V] // Required. Describe FSM graph
-		StatusValMap map[T]V                 // Optional. Store custom value in abstract status
+		DescList     []*DescCell[T, S, U, V]       // Required. Describe FSM graph
+		StatusValMap map[T]V                       // Optional. Store custom value in abstract status
+		DeferMap     map[T][]S                     // Optional. Events deferred by state instead of being rejected
+		PseudoMap    map[T]*PseudoDesc[T, S, U, V] // Optional. Choice and junction pseudo-states
+	}
+
+	// PseudoDesc Describe one pseudo-state
+	// Choice and junction: FSM never stays in it, but leaves by the first branch whose guard passes.
+	// Fork: FSM stays in it while one region per branch runs in parallel. Guards are ignored.
+	// Join: FSM leaves the fork by its only branch once a region enters a source and every source is reached.
+	// Regions may not start in a source
+	PseudoDesc[T, S comparable, U, V any] struct {
+		Kind     VertexKind                // VertexChoice, VertexJunction, VertexFork or VertexJoin
+		Branches []*BranchDesc[T, S, U, V] // Evaluated in order
+		Sources  []T                       // Join only. State required in each region
+	}
+
+	// BranchDesc Describe one branch leaving a pseudo-state
+	BranchDesc[T, S comparable, U, V any] struct {
+		Guard    func(*Event[T, S, U, V]) bool // Optional. Nil means else branch
+		ToState  T
+		StoreVal U
 	}
 
 	// DescCell Describe one eventE
 	DescCell[T, S comparable, U, V any] struct {
 		EventVal      S
 		FromState     []T
+		FromAny       bool // Optional. From every state except Except, FromState is ignored. Specific transitions take precedence
+		Except        []T  // Optional. Used with FromAny
 		ToState       T
-		EventStoreVal U // Every edge's EventStoreVal in this cell will be assigned this field
+		Internal      bool          // Optional. Run without exiting FromState, ToState must equal every FromState
+		Stack         StackOp       // Optional. StackPush saves FromState before moving. StackPop returns to the saved state, ToState is ignored
+		EventStoreVal U             // Every edge's EventStoreVal in this cell will be assigned this field
+		After         time.Duration // Optional. Trigger EventVal automatically after staying in FromState for this long
+	}
+
+	// pseudoPair PseudoMap entry
+	pseudoPair[T, S comparable, U, V any] struct {
+		state T
+		desc  *PseudoDesc[T, S, U, V]
 	}
 
 	// stateEvent Deduplication helper
@@ -41,8 +75,13 @@ func (fac *DefConfig[T, S, U, V]) NewG() (*Graph[T, S, U, V], error) {
 	// Init itoV
 	var stateValSet gcollection.Set[T] = hashset.NewHashSet[T]()
 	for _, desc := range fac.DescList {
-		if ok := stateValSet.Add(desc.ToState); ok {
-			g.itoV = append(g.itoV, fac.newV(desc.ToState))
+		if desc.Stack != StackPop {
+			if ok := stateValSet.Add(desc.ToState); ok {
+				g.itoV = append(g.itoV, fac.newV(desc.ToState))
+			}
+		}
+		if desc.FromAny {
+			continue
 		}
 		for _, fs := range desc.FromState {
 			if ok := stateValSet.Add(fs); ok {
@@ -51,6 +90,17 @@ func (fac *DefConfig[T, S, U, V]) NewG() (*Graph[T, S, U, V], error) {
 		}
 	}
 
+	for _, pd := range fac.sortedPseudo() {
+		if ok := stateValSet.Add(pd.state); ok {
+			g.itoV = append(g.itoV, fac.newV(pd.state))
+		}
+		for _, b := range pd.desc.Branches {
+			if ok := stateValSet.Add(b.ToState); ok {
+				g.itoV = append(g.itoV, fac.newV(b.ToState))
+			}
+		}
+	}
+
 	// Init idx and stoV
 	// Idx starts with 0
 	for i, v := range g.itoV {
@@ -58,20 +108,49 @@ func (fac *DefConfig[T, S, U, V]) NewG() (*Graph[T, S, U, V], error) {
 		g.stoV[v.stateVal] = v
 	}
 
+	// Mark pseudo-states. Never a source of events
+	for state, pd := range fac.PseudoMap {
+		v := g.VertexByState(state)
+		if v == nil {
+			return nil, &StateNotExistErr[T]{State: state}
+		}
+		switch pd.Kind {
+		case VertexChoice, VertexJunction:
+		case VertexFork:
+			if len(pd.Branches) == 0 {
+				return nil, &InvalidPseudoStateErr[T]{State: state, Reason: "fork needs one branch per region"}
+			}
+		case VertexJoin:
+			if len(pd.Sources) == 0 || len(pd.Branches) != 1 {
+				return nil, &InvalidPseudoStateErr[T]{State: state, Reason: "join needs sources and exactly one branch"}
+			}
+		default:
+			return nil, &InvalidPseudoStateErr[T]{State: state, Reason: "unknown kind"}
+		}
+		v.kind = pd.Kind
+	}
+	for _, d := range fac.DescList {
+		for _, s := range d.FromState {
+			if !d.FromAny && g.VertexByState(s).Pseudo() {
+				return nil, &InvalidPseudoStateErr[T]{State: s, Reason: "pseudo-state can not handle events"}
+			}
+		}
+	}
+
+	pushed, err := fac.pushSources(g)
+	if err != nil {
+		return nil, err
+	}
+
 	// initial adj
 	vl := len(g.itoV)
 	var stateEventSet gcollection.Set[stateEvent[T, S]] = hashset.NewHashSet[stateEvent[T, S]]()
 	g.adj = make([]*EdgeCollection[T, S, U, V], vl, vl)
 	for _, d := range fac.DescList {
-		toIdx := g.VertexByState(d.ToState).idx
+		if d.FromAny {
+			continue
+		}
 		for _, s := range d.FromState {
-			fromIdx := g.VertexByState(s).idx
-			if g.adj[fromIdx] == nil {
-				g.adj[fromIdx] = &EdgeCollection[T, S, U, V]{
-					eList: make([]*Edge[T, S, U, V], 0),
-					eFast: make(map[S][]*Edge[T, S, U, V]),
-				}
-			}
 			uniqSE := stateEvent[T, S]{
 				stateVal: s,
 				eventVal: d.EventVal,
@@ -79,19 +158,217 @@ func (fac *DefConfig[T, S, U, V]) NewG() (*Graph[T, S, U, V], error) {
 			if ok := stateEventSet.Add(uniqSE); !ok {
 				return nil, &DuplicateStateAndEventErr[T, S]{State: s, Event: d.EventVal}
 			}
-			e := &Edge[T, S, U, V]{
-				fromV:    g.itoV[fromIdx],
-				toV:      g.itoV[toIdx],
+			if d.Internal && (s != d.ToState || d.Stack != StackNone) {
+				return nil, &InvalidInternalTransitionErr[T, S]{State: s, Event: d.EventVal}
+			}
+			if d.Stack == StackPop && len(pushed) == 0 {
+				return nil, &NoPushErr[S]{Event: d.EventVal}
+			}
+			for _, e := range fac.newEs(g, s, d, pushed) {
+				g.addE(e)
+			}
+		}
+	}
+
+	// Expand wildcard cells after all specific ones, so that specific transitions shadow them
+	var wildcardSet gcollection.Set[stateEvent[T, S]] = hashset.NewHashSet[stateEvent[T, S]]()
+	for _, d := range fac.DescList {
+		if !d.FromAny {
+			continue
+		}
+		if d.Internal {
+			return nil, &InvalidInternalTransitionErr[T, S]{State: d.ToState, Event: d.EventVal}
+		}
+		if d.Stack == StackPop && len(pushed) == 0 {
+			return nil, &NoPushErr[S]{Event: d.EventVal}
+		}
+		var exceptSet gcollection.Set[T] = hashset.NewHashSet[T]()
+		for _, s := range d.Except {
+			if g.VertexByState(s) == nil {
+				return nil, &StateNotExistErr[T]{State: s}
+			}
+			exceptSet.Add(s)
+		}
+		for _, v := range g.itoV {
+			if v.Pseudo() || exceptSet.Contains(v.stateVal) {
+				continue
+			}
+			uniqSE := stateEvent[T, S]{
+				stateVal: v.stateVal,
 				eventVal: d.EventVal,
-				storeVal: d.EventStoreVal,
 			}
-			g.adj[fromIdx].addE(e)
+			if stateEventSet.Contains(uniqSE) {
+				continue
+			}
+			if ok := wildcardSet.Add(uniqSE); !ok {
+				return nil, &DuplicateStateAndEventErr[T, S]{State: v.stateVal, Event: d.EventVal}
+			}
+			for _, e := range fac.newEs(g, v.stateVal, d, pushed) {
+				e.wildcard = true
+				g.addE(e)
+			}
 		}
 	}
 
+	// Branches of pseudo-states
+	for _, pd := range fac.sortedPseudo() {
+		fromV := g.VertexByState(pd.state)
+		for _, b := range pd.desc.Branches {
+			toV := g.VertexByState(b.ToState)
+			if pd.desc.Kind == VertexFork && toV.kind != VertexState {
+				return nil, &InvalidPseudoStateErr[T]{State: pd.state, Reason: "region must start in a regular state"}
+			}
+			g.addBranch(&Edge[T, S, U, V]{
+				fromV:    fromV,
+				toV:      toV,
+				storeVal: b.StoreVal,
+				guard:    b.Guard,
+			})
+		}
+		if pd.desc.Kind != VertexJoin {
+			continue
+		}
+		sources := make([]*Vertex[T, V], 0, len(pd.desc.Sources))
+		for _, s := range pd.desc.Sources {
+			sv := g.VertexByState(s)
+			if sv == nil {
+				return nil, &StateNotExistErr[T]{State: s}
+			}
+			sources = append(sources, sv)
+			g.addBranch(&Edge[T, S, U, V]{
+				fromV: sv,
+				toV:   fromV,
+			})
+		}
+		g.joins = append(g.joins, &join[T, V]{v: fromV, sources: sources})
+	}
+
+	// A region starting in a join source never moves into it, so the join could not fire
+	for _, pd := range fac.sortedPseudo() {
+		if pd.desc.Kind != VertexFork {
+			continue
+		}
+		for _, b := range pd.desc.Branches {
+			for _, j := range g.joins {
+				for _, sv := range j.sources {
+					if sv.stateVal == b.ToState {
+						return nil, &InvalidPseudoStateErr[T]{State: pd.state, Reason: "region must not start in a join source"}
+					}
+				}
+			}
+		}
+	}
+
+	for state, events := range fac.DeferMap {
+		if g.VertexByState(state) == nil {
+			return nil, &StateNotExistErr[T]{State: state}
+		}
+		g.SetDefers(state, events...)
+	}
+
 	return g, nil
 }
 
+// sortedPseudo PseudoMap in vertex order of DescList, so that idx is stable between runs
+func (fac *DefConfig[T, S, U, V]) sortedPseudo() []*pseudoPair[T, S, U, V] {
+	resp := make([]*pseudoPair[T, S, U, V], 0, len(fac.PseudoMap))
+	var seen gcollection.Set[T] = hashset.NewHashSet[T]()
+	visit := func(s T) {
+		if pd, ok := fac.PseudoMap[s]; ok && seen.Add(s) {
+			resp = append(resp, &pseudoPair[T, S, U, V]{state: s, desc: pd})
+		}
+	}
+	for _, d := range fac.DescList {
+		visit(d.ToState)
+	}
+	for i := 0; ; {
+		// Pseudo-states only reachable from other pseudo-states
+		for ; i < len(resp); i += 1 {
+			for _, b := range resp[i].desc.Branches {
+				visit(b.ToState)
+			}
+		}
+		// Joins are reached from their sources instead of being a target
+		var joins []T
+		for s, pd := range fac.PseudoMap {
+			if pd.Kind == VertexJoin && !seen.Contains(s) {
+				joins = append(joins, s)
+			}
+		}
+		if len(joins) == 0 {
+			return resp
+		}
+		sort.Slice(joins, func(i, j int) bool {
+			return fmt.Sprintf("%v", joins[i]) < fmt.Sprintf("%v", joins[j])
+		})
+		for _, s := range joins {
+			visit(s)
+		}
+	}
+}
+
+// pushSources States that push cells may save on the stack, in vertex order
+func (fac *DefConfig[T, S, U, V]) pushSources(g *Graph[T, S, U, V]) ([]*Vertex[T, V], error) {
+	var pushSet gcollection.Set[T] = hashset.NewHashSet[T]()
+	for _, d := range fac.DescList {
+		if d.Stack != StackPush {
+			continue
+		}
+		if !d.FromAny {
+			for _, s := range d.FromState {
+				pushSet.Add(s)
+			}
+			continue
+		}
+		var exceptSet gcollection.Set[T] = hashset.NewHashSet[T]()
+		for _, s := range d.Except {
+			exceptSet.Add(s)
+		}
+		for _, v := range g.itoV {
+			if !v.Pseudo() && !exceptSet.Contains(v.stateVal) {
+				pushSet.Add(v.stateVal)
+			}
+		}
+	}
+	var resp []*Vertex[T, V]
+	for _, v := range g.itoV {
+		if !pushSet.Contains(v.stateVal) {
+			continue
+		}
+		if v.kind != VertexState && v.kind != VertexFork {
+			return nil, &InvalidPseudoStateErr[T]{State: v.stateVal, Reason: "pseudo-state can not be pushed"}
+		}
+		resp = append(resp, v)
+	}
+	return resp, nil
+}
+
+// newEs New edges from fromState described by d
+// A pop cell leads to every pushed state, the one on top of the stack is picked at runtime
+func (fac *DefConfig[T, S, U, V]) newEs(g *Graph[T, S, U, V], fromState T, d *DescCell[T, S, U, V], pushed []*Vertex[T, V]) []*Edge[T, S, U, V] {
+	if d.Stack != StackPop {
+		return []*Edge[T, S, U, V]{fac.newE(g, fromState, g.VertexByState(d.ToState), d)}
+	}
+	resp := make([]*Edge[T, S, U, V], 0, len(pushed))
+	for _, toV := range pushed {
+		resp = append(resp, fac.newE(g, fromState, toV, d))
+	}
+	return resp
+}
+
+// newE New an edge from fromState to toV described by d
+func (fac *DefConfig[T, S, U, V]) newE(g *Graph[T, S, U, V], fromState T, toV *Vertex[T, V], d *DescCell[T, S, U, V]) *Edge[T, S, U, V] {
+	return &Edge[T, S, U, V]{
+		fromV:    g.VertexByState(fromState),
+		toV:      toV,
+		eventVal: d.EventVal,
+		storeVal: d.EventStoreVal,
+		after:    d.After,
+		internal: d.Internal,
+		stackOp:  d.Stack,
+	}
+}
+
 // newV Without idx, autofill storeVal
 func (fac *DefConfig[T, S, U, V]) newV(state T) *Vertex[T, V] {
 	genV := &Vertex[T, V]{
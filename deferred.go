@@ -0,0 +1,89 @@
+package fsm
+
+// deferredEvent An event queued by a state that defers it
+type deferredEvent[S comparable] struct {
+	eventVal S
+	args     []interface{}
+}
+
+// DeferredEvents Events waiting for a state that accepts them, in arrival order
+func (f *FSM[T, S, U, V]) DeferredEvents() []S {
+	f.deferMutex.Lock()
+	defer f.deferMutex.Unlock()
+	resp := make([]S, 0, len(f.deferQueue))
+	for _, d := range f.deferQueue {
+		resp = append(resp, d.eventVal)
+	}
+	return resp
+}
+
+// enqueueDeferred Caller must hold the lock
+func (f *FSM[T, S, U, V]) enqueueDeferred(eventVal S, args []interface{}) {
+	f.deferMutex.Lock()
+	defer f.deferMutex.Unlock()
+	f.deferQueue = append(f.deferQueue, &deferredEvent[S]{
+		eventVal: eventVal,
+		args:     args,
+	})
+}
+
+// popDeferred Remove and return the first event that state accepts and no longer defers. Caller must hold the lock
+// Other events stay queued for later states
+func (f *FSM[T, S, U, V]) popDeferred(state T) *deferredEvent[S] {
+	f.deferMutex.Lock()
+	defer f.deferMutex.Unlock()
+	for i, d := range f.deferQueue {
+		if f.g.Defers(state, d.eventVal) || !f.accepts(state, d.eventVal) {
+			continue
+		}
+		f.deferQueue = append(f.deferQueue[:i:i], f.deferQueue[i+1:]...)
+		return d
+	}
+	return nil
+}
+
+// accepts Whether state or one of current regions has an edge for eventVal
+func (f *FSM[T, S, U, V]) accepts(state T, eventVal S) bool {
+	if _, err := f.g.NextEdge(state, eventVal); err == nil {
+		return true
+	}
+	_, regionEdge := f.regionEdge(eventVal)
+	return regionEdge != nil
+}
+
+// dispatchDeferred Re-dispatch queued events accepted by current state
+// Their errors have no caller to return to, so they go to OnDeferredErr. Caller must hold the lock
+func (f *FSM[T, S, U, V]) dispatchDeferred() {
+	for {
+		d := f.popDeferred(f.CurrState())
+		if d == nil {
+			return
+		}
+		if _, err := f.handle(d.eventVal, d.args); err != nil {
+			f.reportDeferredErr(err)
+		}
+	}
+}
+
+// reportDeferredErr Send an error of a re-dispatched event to OnDeferredErr
+func (f *FSM[T, S, U, V]) reportDeferredErr(err error) {
+	f.deferMutex.Lock()
+	onDeferredErr := f.onDeferredErr
+	f.deferMutex.Unlock()
+	if onDeferredErr != nil {
+		onDeferredErr(err)
+	}
+}
+
+func (f *FSM[T, S, U, V]) OnDeferredErr() func(error) {
+	f.deferMutex.Lock()
+	defer f.deferMutex.Unlock()
+	return f.onDeferredErr
+}
+
+// SetOnDeferredErr receive errors of deferred events re-dispatched after a state change
+func (f *FSM[T, S, U, V]) SetOnDeferredErr(onDeferredErr func(error)) {
+	f.deferMutex.Lock()
+	defer f.deferMutex.Unlock()
+	f.onDeferredErr = onDeferredErr
+}
@@ -0,0 +1,93 @@
+package fsm
+
+import (
+	"errors"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestFSM_Deferred(t *testing.T) {
+
+	fac := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+		DescList: descFac.DescList,
+		DeferMap: map[nodeState][]eventVal{
+			initial: {deliverEvent, receiveEvent},
+			paid:    {receiveEvent},
+		},
+	}
+	testFSM, err := NewFsm[nodeState, eventVal, edgeVal, nodeVal](fac, initial)
+	assert.NilError(t, err)
+
+	e, err := testFSM.Trigger(receiveEvent)
+	assert.NilError(t, err)
+	assert.Assert(t, e.Deferred())
+	e, err = testFSM.Trigger(deliverEvent)
+	assert.NilError(t, err)
+	assert.Assert(t, e.Deferred())
+	assert.DeepEqual(t, testFSM.DeferredEvents(), []eventVal{receiveEvent, deliverEvent})
+
+	// Not deferred by initial
+	_, err = testFSM.Trigger(cancelEvent)
+	_, ok := err.(*InvalidEventErr[nodeState, eventVal])
+	assert.Assert(t, ok)
+
+	// paid accepts deliverEvent, then delivering accepts receiveEvent
+	e, err = testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, e.ToState(), nodeState(paid))
+	assert.Equal(t, testFSM.CurrState(), nodeState(done))
+	assert.Equal(t, len(testFSM.DeferredEvents()), 0)
+}
+
+func TestFSM_Deferred_Retain(t *testing.T) {
+
+	fac := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+		DescList: descFac.DescList,
+		DeferMap: map[nodeState][]eventVal{
+			initial: {readyEvent},
+		},
+	}
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](fac, initial)
+	_, _ = testFSM.Trigger(readyEvent)
+	_, err := testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+
+	// paid neither defers nor accepts readyEvent, so it stays queued
+	assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+	assert.DeepEqual(t, testFSM.DeferredEvents(), []eventVal{readyEvent})
+
+	// canceled accepts it
+	_, err = testFSM.Trigger(cancelEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, testFSM.CurrState(), nodeState(initial))
+	assert.Equal(t, testFSM.PrevState(), nodeState(canceled))
+	assert.Equal(t, len(testFSM.DeferredEvents()), 0)
+}
+
+func TestFSM_Deferred_Err(t *testing.T) {
+
+	fac := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+		DescList: descFac.DescList,
+		DeferMap: map[nodeState][]eventVal{
+			initial: {deliverEvent},
+		},
+	}
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](fac, initial)
+	testFSM.AddBeforeStateChange(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		if e.EventVal() == deliverEvent {
+			return errors.New("no courier")
+		}
+		return nil
+	}, 0)
+	var errs []error
+	testFSM.SetOnDeferredErr(func(err error) {
+		errs = append(errs, err)
+	})
+
+	_, _ = testFSM.Trigger(deliverEvent)
+	_, err := testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], "no courier")
+}
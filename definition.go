@@ -0,0 +1,118 @@
+package fsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+type (
+	// Definition Machine description in JSON, shared by cmd/fsmgen and cmd/fsm
+	// States and events are strings. Store values of edges are strings, vertices store nothing
+	Definition struct {
+		Name        string                  `json:"name"`             // Name of the machine. Used as type name prefix by fsmgen
+		Initial     string                  `json:"initial"`          // Initial state
+		States      []string                `json:"states,omitempty"` // Optional. Fix state order, states only used by transitions are appended
+		Transitions []*TransitionDefinition `json:"transitions"`
+		Defer       map[string][]string     `json:"defer,omitempty"` // Optional. Same as DefConfig.DeferMap
+	}
+
+	// TransitionDefinition One DescCell in JSON
+	TransitionDefinition struct {
+		Event    string   `json:"event"`
+		From     []string `json:"from,omitempty"`
+		FromAny  bool     `json:"from_any,omitempty"`
+		Except   []string `json:"except,omitempty"`
+		To       string   `json:"to,omitempty"`
+		Internal bool     `json:"internal,omitempty"`
+		Store    string   `json:"store,omitempty"` // EventStoreVal
+		After    string   `json:"after,omitempty"` // Duration in time.ParseDuration format
+		Stack    string   `json:"stack,omitempty"` // "push" or "pop"
+	}
+)
+
+// Ensure interface implement
+var _ GraphConfig[string, string, string, NA] = new(Definition)
+
+// LoadDefinition decode a Definition from JSON. Unknown fields are rejected to catch typos
+func LoadDefinition(r io.Reader) (*Definition, error) {
+	d := &Definition{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// NewG New a Graph
+func (d *Definition) NewG() (*Graph[string, string, string, NA], error) {
+	fac, err := d.DefConfig()
+	if err != nil {
+		return nil, err
+	}
+	g, err := fac.NewG()
+	if err != nil {
+		return nil, err
+	}
+	if g.VertexByState(d.Initial) == nil {
+		return nil, &StateNotExistErr[string]{State: d.Initial}
+	}
+	return g, nil
+}
+
+// DefConfig Convert to a DefConfig. States listed in States come first in vertex order
+func (d *Definition) DefConfig() (*DefConfig[string, string, string, NA], error) {
+	fac := &DefConfig[string, string, string, NA]{
+		DeferMap: d.Defer,
+	}
+
+	// Cells without event only declare states, so that they get idx in listed order
+	for _, s := range d.States {
+		fac.DescList = append(fac.DescList, &DescCell[string, string, string, NA]{ToState: s})
+	}
+
+	for _, t := range d.Transitions {
+		cell := &DescCell[string, string, string, NA]{
+			EventVal:      t.Event,
+			FromState:     t.From,
+			FromAny:       t.FromAny,
+			Except:        t.Except,
+			ToState:       t.To,
+			Internal:      t.Internal,
+			EventStoreVal: t.Store,
+		}
+		if t.After != "" {
+			after, err := time.ParseDuration(t.After)
+			if err != nil {
+				return nil, err
+			}
+			cell.After = after
+		}
+		switch t.Stack {
+		case "":
+		case "push":
+			cell.Stack = StackPush
+		case "pop":
+			cell.Stack = StackPop
+		default:
+			return nil, &InvalidDefinitionErr{Reason: fmt.Sprintf("unknown stack operation %q of event %s", t.Stack, t.Event)}
+		}
+		fac.DescList = append(fac.DescList, cell)
+	}
+	return fac, nil
+}
+
+// Events Distinct event values in order of first appearance
+func (d *Definition) Events() []string {
+	var resp []string
+	seen := make(map[string]struct{})
+	for _, t := range d.Transitions {
+		if _, ok := seen[t.Event]; !ok {
+			seen[t.Event] = struct{}{}
+			resp = append(resp, t.Event)
+		}
+	}
+	return resp
+}
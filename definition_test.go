@@ -0,0 +1,42 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefinition(t *testing.T) {
+
+	d, err := LoadDefinition(strings.NewReader(`{
+		"name": "order",
+		"initial": "initial",
+		"states": ["initial", "paid"],
+		"transitions": [
+			{"event": "pay", "from": ["initial"], "to": "paid", "store": "Thanks"},
+			{"event": "cancel", "from": ["paid"], "to": "canceled", "after": "30s"}
+		]
+	}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, d.Events(), []string{"pay", "cancel"})
+
+	g, err := d.NewG()
+	assert.NilError(t, err)
+	assert.Equal(t, g.VertexByIdx(0).StateVal(), "initial")
+	assert.Equal(t, g.VertexByIdx(2).StateVal(), "canceled")
+	edge, err := g.NextEdge("paid", "cancel")
+	assert.NilError(t, err)
+	assert.Equal(t, edge.After(), 30*time.Second)
+	edge, _ = g.NextEdge("initial", "pay")
+	assert.Equal(t, edge.StoreVal(), "Thanks")
+
+	// Typos are rejected
+	_, err = LoadDefinition(strings.NewReader(`{"initial": "a", "transition": []}`))
+	assert.Assert(t, err != nil)
+
+	d.Initial = "unknown"
+	_, err = d.NewG()
+	_, ok := err.(*StateNotExistErr[string])
+	assert.Assert(t, ok)
+}
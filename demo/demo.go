@@ -1,5 +1,7 @@
 package demo
 
+//go:generate go run github.com/kiexu/go-generic-fsm/cmd/fsmgen -in order.json
+
 import (
 	"github.com/kiexu/go-generic-fsm"
 )
@@ -37,3 +39,9 @@ func demo() {
 	demoFsm, _ := fsm.NewFsm[string, string, string, fsm.NA](demoFac, "initial")
 	_, _ = demoFsm.Trigger("payEvent")
 }
+
+func generatedDemo() {
+	order, _ := NewOrder() // Generated from order.json
+	_, _ = order.Pay()
+	_ = order.CurrState() == OrderStatePaid
+}
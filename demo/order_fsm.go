@@ -0,0 +1,130 @@
+// Code generated by fsmgen. DO NOT EDIT.
+
+package demo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kiexu/go-generic-fsm"
+)
+
+// OrderState State of Order
+type OrderState int
+
+const (
+	OrderStateInitial OrderState = iota
+	OrderStatePaid
+	OrderStateDone
+	OrderStateCanceled
+)
+
+var orderStateNames = [...]string{
+	"initial",
+	"paid",
+	"done",
+	"canceled",
+}
+
+func (v OrderState) String() string {
+	if v < 0 || int(v) >= len(orderStateNames) {
+		return "OrderState(" + strconv.Itoa(int(v)) + ")"
+	}
+	return orderStateNames[v]
+}
+
+// OrderEvent Event of Order
+type OrderEvent int
+
+const (
+	OrderEventPay OrderEvent = iota
+	OrderEventDeliver
+	OrderEventAbort
+	OrderEventReady
+)
+
+var orderEventNames = [...]string{
+	"pay",
+	"deliver",
+	"abort",
+	"ready",
+}
+
+func (v OrderEvent) String() string {
+	if v < 0 || int(v) >= len(orderEventNames) {
+		return "OrderEvent(" + strconv.Itoa(int(v)) + ")"
+	}
+	return orderEventNames[v]
+}
+
+// NewOrderConfig Config of Order
+func NewOrderConfig() *fsm.DefConfig[OrderState, OrderEvent, string, fsm.NA] {
+	return &fsm.DefConfig[OrderState, OrderEvent, string, fsm.NA]{
+		DescList: []*fsm.DescCell[OrderState, OrderEvent, string, fsm.NA]{
+			{
+				EventVal:      OrderEventPay,
+				FromState:     []OrderState{OrderStateInitial},
+				ToState:       OrderStatePaid,
+				EventStoreVal: "Thanks",
+			},
+			{
+				EventVal:      OrderEventDeliver,
+				FromState:     []OrderState{OrderStatePaid},
+				ToState:       OrderStateDone,
+				EventStoreVal: "Coming",
+			},
+			{
+				EventVal:      OrderEventAbort,
+				FromState:     []OrderState{OrderStatePaid},
+				ToState:       OrderStateCanceled,
+				EventStoreVal: "CancelOK",
+				After:         30 * time.Minute,
+			},
+			{
+				EventVal:      OrderEventReady,
+				FromState:     []OrderState{OrderStateDone, OrderStateCanceled},
+				ToState:       OrderStateInitial,
+				EventStoreVal: "ResetOK",
+			},
+		},
+	}
+}
+
+// Order FSM with one method per event
+type Order struct {
+	*fsm.FSM[OrderState, OrderEvent, string, fsm.NA]
+}
+
+// NewOrder new a Order in initial
+func NewOrder() (*Order, error) {
+	return NewOrderAt(OrderStateInitial)
+}
+
+// NewOrderAt new a Order in given state
+func NewOrderAt(initState OrderState) (*Order, error) {
+	f, err := fsm.NewFsm[OrderState, OrderEvent, string, fsm.NA](NewOrderConfig(), initState)
+	if err != nil {
+		return nil, err
+	}
+	return &Order{FSM: f}, nil
+}
+
+// Pay trigger pay
+func (m *Order) Pay(args ...interface{}) (*fsm.Event[OrderState, OrderEvent, string, fsm.NA], error) {
+	return m.Trigger(OrderEventPay, args...)
+}
+
+// Deliver trigger deliver
+func (m *Order) Deliver(args ...interface{}) (*fsm.Event[OrderState, OrderEvent, string, fsm.NA], error) {
+	return m.Trigger(OrderEventDeliver, args...)
+}
+
+// Abort trigger abort
+func (m *Order) Abort(args ...interface{}) (*fsm.Event[OrderState, OrderEvent, string, fsm.NA], error) {
+	return m.Trigger(OrderEventAbort, args...)
+}
+
+// Ready trigger ready
+func (m *Order) Ready(args ...interface{}) (*fsm.Event[OrderState, OrderEvent, string, fsm.NA], error) {
+	return m.Trigger(OrderEventReady, args...)
+}
@@ -1,5 +1,18 @@
 package fsm
 
+import (
+	"time"
+)
+
+// StackOp Operation on the state stack of a pushdown FSM
+type StackOp int
+
+const (
+	StackNone StackOp = iota // Regular transition
+	StackPush                // Push current state, then move to toV
+	StackPop                 // Move back to the state on top of the stack. One edge per possible top
+)
+
 type (
 	// EdgeCollection fast query supported
 	EdgeCollection[T, S comparable, U, V any] struct {
@@ -13,18 +26,46 @@ type (
 		toV      *Vertex[T, V] // To vertex
 		eventVal S             // Event value. Not unique
 		storeVal U             // Anything you want. e.g. Real callback function(use Callbacks to invoke)
+		after    time.Duration // Trigger automatically after staying in fromV for this long. 0 means never
+		wildcard bool          // Expanded from a "from any state" description
+		internal bool          // Internal transition. fromV == toV and the state is not exited
+		stackOp  StackOp       // Push or pop the state stack
+
+		branch bool                          // Eventless branch of a pseudo-state, fork region or join
+		guard  func(*Event[T, S, U, V]) bool // Guard of a branch leaving a pseudo-state. Nil means always
 	}
 )
 
 // EdgeCollection
 
 // addE add an edge to EdgeCollection
+// Specific edges are always queried before wildcard ones
 func (c *EdgeCollection[T, S, U, V]) addE(e *Edge[T, S, U, V]) {
 	if e == nil {
 		return
 	}
 	c.eList = append(c.eList, e)
-	c.eFast[e.eventVal] = append(c.eFast[e.eventVal], e)
+	fast := c.eFast[e.eventVal]
+	i := len(fast)
+	if !e.wildcard {
+		for i > 0 && fast[i-1].wildcard {
+			i -= 1
+		}
+	}
+	fast = append(fast, nil)
+	copy(fast[i+1:], fast[i:])
+	fast[i] = e
+	c.eFast[e.eventVal] = fast
+}
+
+// addBranch add a branch leaving a pseudo-state
+// Branches have no event, so they are only in eList
+func (c *EdgeCollection[T, S, U, V]) addBranch(e *Edge[T, S, U, V]) {
+	if e == nil {
+		return
+	}
+	e.branch = true
+	c.eList = append(c.eList, e)
 }
 
 // EdgeByEventVal get eventE value by eventE value
@@ -73,3 +114,49 @@ func (e *Edge[T, S, U, V]) StoreVal() U {
 func (e *Edge[T, S, U, V]) SetStoreVal(storeVal U) {
 	e.storeVal = storeVal
 }
+
+func (e *Edge[T, S, U, V]) After() time.Duration {
+	return e.after
+}
+
+func (e *Edge[T, S, U, V]) SetAfter(after time.Duration) {
+	e.after = after
+}
+
+// Wildcard Whether the edge comes from a "from any state" description
+func (e *Edge[T, S, U, V]) Wildcard() bool {
+	return e.wildcard
+}
+
+func (e *Edge[T, S, U, V]) SetWildcard(wildcard bool) {
+	e.wildcard = wildcard
+}
+
+func (e *Edge[T, S, U, V]) Internal() bool {
+	return e.internal
+}
+
+func (e *Edge[T, S, U, V]) SetInternal(internal bool) {
+	e.internal = internal
+}
+
+func (e *Edge[T, S, U, V]) Guard() func(*Event[T, S, U, V]) bool {
+	return e.guard
+}
+
+func (e *Edge[T, S, U, V]) SetGuard(guard func(*Event[T, S, U, V]) bool) {
+	e.guard = guard
+}
+
+// Branch Whether the edge is an eventless branch
+func (e *Edge[T, S, U, V]) Branch() bool {
+	return e.branch
+}
+
+func (e *Edge[T, S, U, V]) StackOp() StackOp {
+	return e.stackOp
+}
+
+func (e *Edge[T, S, U, V]) SetStackOp(stackOp StackOp) {
+	e.stackOp = stackOp
+}
@@ -2,6 +2,8 @@ package fsm
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 // DuplicateStateAndEventErr Pair of state and event is not unique
@@ -40,3 +42,215 @@ type VisualPackNotInitErr struct {
 func (e VisualPackNotInitErr) Error() string {
 	return "visualization package is not initialized"
 }
+
+// MailboxNotStartedErr FSM.Start haven't been called
+type MailboxNotStartedErr struct {
+}
+
+func (e MailboxNotStartedErr) Error() string {
+	return "mailbox is not started"
+}
+
+// MailboxStartedErr FSM.Start called twice without Stop
+type MailboxStartedErr struct {
+}
+
+func (e MailboxStartedErr) Error() string {
+	return "mailbox is already started"
+}
+
+// MailboxStoppedErr Mailbox is stopping and accepts no more events
+type MailboxStoppedErr struct {
+}
+
+func (e MailboxStoppedErr) Error() string {
+	return "mailbox is stopped"
+}
+
+// MailboxFullErr Mailbox reached its capacity
+type MailboxFullErr struct {
+}
+
+func (e MailboxFullErr) Error() string {
+	return "mailbox is full"
+}
+
+// BusyErr Another Trigger is in progress
+type BusyErr struct {
+}
+
+func (e BusyErr) Error() string {
+	return "another transition is in progress"
+}
+
+// LockTimeoutErr Lock can not be acquired in time
+type LockTimeoutErr struct {
+	Timeout time.Duration
+}
+
+func (e LockTimeoutErr) Error() string {
+	return fmt.Sprintf("lock can not be acquired within %v", e.Timeout)
+}
+
+// TimerStoreNotSetErr FSM.SetTimerStore haven't been called
+type TimerStoreNotSetErr struct {
+}
+
+func (e TimerStoreNotSetErr) Error() string {
+	return "timer store is not set"
+}
+
+// InvalidInternalTransitionErr Internal transition must start and end in the same specific state
+type InvalidInternalTransitionErr[T, S comparable] struct {
+	State T
+	Event S
+}
+
+func (e InvalidInternalTransitionErr[T, S]) Error() string {
+	return fmt.Sprintf("internal transition of event %v must stay in state %v", e.Event, e.State)
+}
+
+// InvalidPseudoStateErr Pseudo-state is misconfigured
+type InvalidPseudoStateErr[T comparable] struct {
+	State  T
+	Reason string
+}
+
+func (e InvalidPseudoStateErr[T]) Error() string {
+	return fmt.Sprintf("invalid pseudo-state %v: %s", e.State, e.Reason)
+}
+
+// NoBranchErr No guard passes on a pseudo-state
+type NoBranchErr[T comparable] struct {
+	State T
+}
+
+func (e NoBranchErr[T]) Error() string {
+	return fmt.Sprintf("no branch of pseudo-state %v can be taken", e.State)
+}
+
+// EmptyStackErr Pop transition triggered while the state stack is empty
+type EmptyStackErr[T, S comparable] struct {
+	State T
+	Event S
+}
+
+func (e EmptyStackErr[T, S]) Error() string {
+	return fmt.Sprintf("event %v pops an empty state stack in state %v", e.Event, e.State)
+}
+
+// NoPushErr Pop transition is described while no transition pushes, so it could never be taken
+type NoPushErr[S comparable] struct {
+	Event S
+}
+
+func (e NoPushErr[S]) Error() string {
+	return fmt.Sprintf("event %v pops the state stack but no transition pushes", e.Event)
+}
+
+// GuardRejectedErr Guard of a TypedFSM rejected the event
+type GuardRejectedErr[T, S comparable] struct {
+	State T
+	Event S
+}
+
+func (e GuardRejectedErr[T, S]) Error() string {
+	return fmt.Sprintf("guard rejected event %v in state %v", e.Event, e.State)
+}
+
+// abortHooks A rejected event must not reach later hooks, even with HookCollectAll
+func (e GuardRejectedErr[T, S]) abortHooks() {}
+
+// HookErrs Errors of every failed hook of one phase, in running order
+type HookErrs struct {
+	Errs []error
+}
+
+func (e HookErrs) Error() string {
+	msgs := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap support errors.Is and errors.As since go 1.20
+func (e HookErrs) Unwrap() []error {
+	return e.Errs
+}
+
+// UnsupportedSCXMLErr SCXML constructs without a counterpart
+type UnsupportedSCXMLErr struct {
+	Constructs []string
+}
+
+func (e UnsupportedSCXMLErr) Error() string {
+	return fmt.Sprintf("unsupported SCXML constructs: %s", strings.Join(e.Constructs, "; "))
+}
+
+// InvalidSCXMLErr SCXML document is malformed
+type InvalidSCXMLErr struct {
+	Reason string
+}
+
+func (e InvalidSCXMLErr) Error() string {
+	return fmt.Sprintf("invalid SCXML document: %s", e.Reason)
+}
+
+// InvalidDefinitionErr Definition can not be converted to a Graph
+type InvalidDefinitionErr struct {
+	Reason string
+}
+
+func (e InvalidDefinitionErr) Error() string {
+	return fmt.Sprintf("invalid definition: %s", e.Reason)
+}
+
+// InvalidPathLengthErr KPaths needs paths of at least one event
+type InvalidPathLengthErr struct {
+	K int
+}
+
+func (e InvalidPathLengthErr) Error() string {
+	return fmt.Sprintf("path length %d must be at least 1", e.K)
+}
+
+// UnexpectedStateErr State after an event differs from the planned one
+type UnexpectedStateErr[T comparable] struct {
+	Want T
+	Got  T
+}
+
+func (e UnexpectedStateErr[T]) Error() string {
+	return fmt.Sprintf("expected state %v, got %v", e.Want, e.Got)
+}
+
+// SequenceFailedErr A TestSequence failed at Step. Step is -1 if the FSM could not be created
+type SequenceFailedErr struct {
+	Sequence int
+	Step     int
+	Err      error
+}
+
+func (e SequenceFailedErr) Error() string {
+	return fmt.Sprintf("sequence %d failed at step %d: %v", e.Sequence, e.Step, e.Err)
+}
+
+// Unwrap support errors.Is and errors.As
+func (e SequenceFailedErr) Unwrap() error {
+	return e.Err
+}
+
+// InvariantViolationErr An invariant of the entered state does not hold
+type InvariantViolationErr[T comparable] struct {
+	State      T
+	Invariant  string
+	RolledBack bool // State before the transition was restored
+}
+
+func (e InvariantViolationErr[T]) Error() string {
+	if e.RolledBack {
+		return fmt.Sprintf("invariant %s of state %v violated, rolled back", e.Invariant, e.State)
+	}
+	return fmt.Sprintf("invariant %s of state %v violated", e.Invariant, e.State)
+}
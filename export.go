@@ -0,0 +1,351 @@
+package fsm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// nodeStyle Highlight of a vertex in exported diagrams
+type nodeStyle int
+
+const (
+	styleNone   nodeStyle = iota
+	styleCurr             // Current state of ExportOpts.FSM
+	stylePrev             // Previous state of ExportOpts.FSM
+	styleRegion           // State of an active orthogonal region
+)
+
+type (
+	// ExportOpts Options of WriteMermaid, WriteDOT and WritePlantUML. Nil means default
+	ExportOpts[T, S comparable, U, V any] struct {
+		FSM      *FSM[T, S, U, V] // Optional. Highlight its current, previous and region states
+		StoreVal bool             // Append edge store values to edge labels
+		Clusters map[string][]T   // Optional. Group states into named boxes. A state is drawn in the first cluster by name
+	}
+
+	// exportModel Diagram independent of output format. Everything is in a stable order
+	exportModel struct {
+		nodes    []*exportNode
+		edges    []*exportEdge
+		clusters []*exportCluster
+	}
+
+	exportNode struct {
+		id    string
+		label string
+		kind  VertexKind
+		style nodeStyle
+	}
+
+	exportEdge struct {
+		from, to string
+		label    string
+	}
+
+	exportCluster struct {
+		name  string
+		nodes []*exportNode
+	}
+)
+
+// WriteMermaid write g as a Mermaid state diagram
+func (g *Graph[T, S, U, V]) WriteMermaid(w io.Writer, opts *ExportOpts[T, S, U, V]) error {
+	m, err := g.exportModel(opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	node := func(indent string, n *exportNode) {
+		switch n.kind {
+		case VertexChoice, VertexJunction:
+			fmt.Fprintf(&b, "%sstate %s <<choice>>\n", indent, n.id)
+		case VertexJoin:
+			fmt.Fprintf(&b, "%sstate %s <<join>>\n", indent, n.id)
+		default:
+			fmt.Fprintf(&b, "%sstate \"%s\" as %s\n", indent, mermaidEscape(n.label), n.id)
+		}
+	}
+	clustered := m.clustered()
+	for _, n := range m.nodes {
+		if !clustered[n] {
+			node("    ", n)
+		}
+	}
+	for i, c := range m.clusters {
+		fmt.Fprintf(&b, "    state \"%s\" as cluster%d {\n", mermaidEscape(c.name), i)
+		for _, n := range c.nodes {
+			node("        ", n)
+		}
+		b.WriteString("    }\n")
+	}
+	for _, e := range m.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "    %s --> %s\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s --> %s : %s\n", e.from, e.to, mermaidEscape(e.label))
+	}
+	styles := []struct {
+		style nodeStyle
+		class string
+		def   string
+	}{
+		{styleCurr, "curr", "fill:#ffd54f,stroke:#f57f17,stroke-width:2px"},
+		{stylePrev, "prev", "fill:#e0e0e0,stroke:#757575"},
+		{styleRegion, "region", "fill:#b3e5fc,stroke:#0277bd"},
+	}
+	for _, s := range styles {
+		var ids []string
+		for _, n := range m.nodes {
+			if n.style == s.style {
+				ids = append(ids, n.id)
+			}
+		}
+		if len(ids) > 0 {
+			fmt.Fprintf(&b, "    classDef %s %s\n", s.class, s.def)
+			fmt.Fprintf(&b, "    class %s %s\n", strings.Join(ids, ","), s.class)
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteDOT write g as a Graphviz digraph
+func (g *Graph[T, S, U, V]) WriteDOT(w io.Writer, opts *ExportOpts[T, S, U, V]) error {
+	m, err := g.exportModel(opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph fsm {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=box, style=rounded];\n")
+	node := func(indent string, n *exportNode) {
+		attrs := []string{fmt.Sprintf("label=\"%s\"", dotEscape(n.label))}
+		switch n.kind {
+		case VertexChoice:
+			attrs = append(attrs, "shape=diamond")
+		case VertexJunction:
+			attrs = append(attrs, "shape=circle", "width=0.2")
+		case VertexJoin:
+			attrs = append(attrs, "shape=box", "style=filled", "fillcolor=black", "fontcolor=white", "height=0.1")
+		case VertexFork:
+			attrs = append(attrs, "peripheries=2")
+		}
+		switch n.style {
+		case styleCurr:
+			attrs = append(attrs, "style=\"rounded,filled,bold\"", "fillcolor=\"#ffd54f\"")
+		case stylePrev:
+			attrs = append(attrs, "style=\"rounded,filled\"", "fillcolor=\"#e0e0e0\"")
+		case styleRegion:
+			attrs = append(attrs, "style=\"rounded,filled\"", "fillcolor=\"#b3e5fc\"")
+		}
+		fmt.Fprintf(&b, "%s%s [%s];\n", indent, n.id, strings.Join(attrs, ", "))
+	}
+	clustered := m.clustered()
+	for _, n := range m.nodes {
+		if !clustered[n] {
+			node("    ", n)
+		}
+	}
+	for i, c := range m.clusters {
+		fmt.Fprintf(&b, "    subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "        label=\"%s\";\n", dotEscape(c.name))
+		for _, n := range c.nodes {
+			node("        ", n)
+		}
+		b.WriteString("    }\n")
+	}
+	for _, e := range m.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "    %s -> %s;\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s -> %s [label=\"%s\"];\n", e.from, e.to, dotEscape(e.label))
+	}
+	b.WriteString("}\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WritePlantUML write g as a PlantUML state diagram
+func (g *Graph[T, S, U, V]) WritePlantUML(w io.Writer, opts *ExportOpts[T, S, U, V]) error {
+	m, err := g.exportModel(opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	node := func(indent string, n *exportNode) {
+		color := ""
+		switch n.style {
+		case styleCurr:
+			color = " #FFD54F"
+		case stylePrev:
+			color = " #E0E0E0"
+		case styleRegion:
+			color = " #B3E5FC"
+		}
+		switch n.kind {
+		case VertexChoice, VertexJunction:
+			fmt.Fprintf(&b, "%sstate %s <<choice>>\n", indent, n.id)
+		case VertexJoin:
+			fmt.Fprintf(&b, "%sstate %s <<join>>\n", indent, n.id)
+		default:
+			fmt.Fprintf(&b, "%sstate \"%s\" as %s%s\n", indent, plantUMLEscape(n.label), n.id, color)
+		}
+	}
+	clustered := m.clustered()
+	for _, n := range m.nodes {
+		if !clustered[n] {
+			node("", n)
+		}
+	}
+	for i, c := range m.clusters {
+		fmt.Fprintf(&b, "state \"%s\" as cluster%d {\n", plantUMLEscape(c.name), i)
+		for _, n := range c.nodes {
+			node("  ", n)
+		}
+		b.WriteString("}\n")
+	}
+	for _, e := range m.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "%s --> %s\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(&b, "%s --> %s : %s\n", e.from, e.to, plantUMLEscape(e.label))
+	}
+	b.WriteString("@enduml\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// exportModel Collect vertices in idx order and edges in registration order
+func (g *Graph[T, S, U, V]) exportModel(opts *ExportOpts[T, S, U, V]) (*exportModel, error) {
+	if opts == nil {
+		opts = &ExportOpts[T, S, U, V]{}
+	}
+
+	m := &exportModel{}
+	for _, v := range g.itoV {
+		m.nodes = append(m.nodes, &exportNode{
+			id:    fmt.Sprintf("s%d", v.idx),
+			label: fmt.Sprintf("%v", v.stateVal),
+			kind:  v.kind,
+		})
+	}
+
+	if opts.FSM != nil {
+		snap := opts.FSM.load()
+		for _, r := range snap.Regions {
+			if v := g.VertexByState(r); v != nil {
+				m.nodes[v.idx].style = styleRegion
+			}
+		}
+		if v := g.VertexByState(snap.Prev); v != nil && snap.Edge != nil {
+			m.nodes[v.idx].style = stylePrev
+		}
+		if v := g.VertexByState(snap.Curr); v != nil {
+			m.nodes[v.idx].style = styleCurr
+		}
+	}
+
+	for _, c := range g.adj {
+		if c == nil {
+			continue
+		}
+		for _, e := range c.eList {
+			m.edges = append(m.edges, &exportEdge{
+				from:  m.nodes[e.fromV.idx].id,
+				to:    m.nodes[e.toV.idx].id,
+				label: edgeLabel(e, opts.StoreVal),
+			})
+		}
+	}
+
+	names := make([]string, 0, len(opts.Clusters))
+	for name := range opts.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seen := make(map[int]struct{})
+	for _, name := range names {
+		c := &exportCluster{name: name}
+		for _, s := range opts.Clusters[name] {
+			v := g.VertexByState(s)
+			if v == nil {
+				return nil, &StateNotExistErr[T]{State: s}
+			}
+			if _, ok := seen[v.idx]; ok {
+				continue
+			}
+			seen[v.idx] = struct{}{}
+			c.nodes = append(c.nodes, m.nodes[v.idx])
+		}
+		m.clusters = append(m.clusters, c)
+	}
+
+	return m, nil
+}
+
+// clustered Nodes drawn inside a cluster
+func (m *exportModel) clustered() map[*exportNode]bool {
+	resp := make(map[*exportNode]bool)
+	for _, c := range m.clusters {
+		for _, n := range c.nodes {
+			resp[n] = true
+		}
+	}
+	return resp
+}
+
+// edgeLabel Event value followed by what else the edge does
+func edgeLabel[T, S comparable, U, V any](e *Edge[T, S, U, V], storeVal bool) string {
+	var parts []string
+	if e.branch {
+		if e.guard != nil {
+			parts = append(parts, "[guard]")
+		} else if e.fromV.kind == VertexChoice || e.fromV.kind == VertexJunction {
+			parts = append(parts, "[else]")
+		}
+	} else {
+		parts = append(parts, fmt.Sprintf("%v", e.eventVal))
+	}
+	if e.after > 0 {
+		parts = append(parts, fmt.Sprintf("after %v", e.after))
+	}
+	if e.internal {
+		parts = append(parts, "internal")
+	}
+	switch e.stackOp {
+	case StackPush:
+		parts = append(parts, "push")
+	case StackPop:
+		parts = append(parts, "pop")
+	}
+	if storeVal && !e.branch {
+		parts = append(parts, fmt.Sprintf("/ %v", e.storeVal))
+	}
+	return strings.Join(parts, " ")
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer("\"", "#quot;", "\n", " ", ":", "#58;").Replace(s)
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(s)
+}
+
+func plantUMLEscape(s string) string {
+	return strings.NewReplacer("\"", "'", "\n", "\\n").Replace(s)
+}
@@ -0,0 +1,83 @@
+package fsm
+
+import (
+	"bytes"
+	"gotest.tools/v3/assert"
+	"strings"
+	"testing"
+)
+
+func TestGraph_WriteMermaid(t *testing.T) {
+
+	testFSM, _ := NewFsm[string, string, NA, NA](approvalFac, "draft")
+	_, _ = testFSM.Trigger("submit")
+	_, _ = testFSM.Trigger("legalOK")
+
+	var buf bytes.Buffer
+	err := testFSM.G().WriteMermaid(&buf, &ExportOpts[string, string, NA, NA]{
+		FSM:      testFSM,
+		Clusters: map[string][]string{"Legal": {"legalReview", "legalDone"}},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, buf.String(), `stateDiagram-v2
+    state "reviewing" as s0
+    state "draft" as s1
+    state "financeDone" as s4
+    state "financeReview" as s5
+    state s6 <<join>>
+    state "approved" as s7
+    state "Legal" as cluster0 {
+        state "legalReview" as s3
+        state "legalDone" as s2
+    }
+    s0 --> s1 : reject
+    s0 --> s3
+    s0 --> s5
+    s1 --> s0 : submit
+    s2 --> s6
+    s3 --> s2 : legalOK
+    s4 --> s6
+    s5 --> s4 : financeOK
+    s6 --> s7
+    classDef curr fill:#ffd54f,stroke:#f57f17,stroke-width:2px
+    class s0 curr
+    classDef prev fill:#e0e0e0,stroke:#757575
+    class s1 prev
+    classDef region fill:#b3e5fc,stroke:#0277bd
+    class s2,s5 region
+`)
+}
+
+func TestGraph_Export(t *testing.T) {
+
+	g, _ := timeoutFac.NewG()
+	write := map[string]func(*bytes.Buffer) error{
+		"dot": func(b *bytes.Buffer) error {
+			return g.WriteDOT(b, nil)
+		},
+		"plantuml": func(b *bytes.Buffer) error {
+			return g.WritePlantUML(b, nil)
+		},
+	}
+	wants := map[string][]string{
+		"dot":      {"digraph fsm {", `s0 -> s3 [label="cancelEvent after 30s"];`},
+		"plantuml": {"@startuml", "s0 --> s3 : cancelEvent after 30s", "@enduml"},
+	}
+	for name, fn := range write {
+		t.Run(name, func(t *testing.T) {
+			var first, second bytes.Buffer
+			assert.NilError(t, fn(&first))
+			assert.NilError(t, fn(&second))
+			assert.Equal(t, first.String(), second.String())
+			for _, want := range wants[name] {
+				assert.Assert(t, strings.Contains(first.String(), want), first.String())
+			}
+		})
+	}
+
+	err := g.WriteDOT(&bytes.Buffer{}, &ExportOpts[nodeState, eventVal, edgeVal, nodeVal]{
+		Clusters: map[string][]nodeState{"x": {nodeState(100)}},
+	})
+	_, ok := err.(*StateNotExistErr[nodeState])
+	assert.Assert(t, ok)
+}
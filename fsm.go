@@ -3,18 +3,48 @@ package fsm
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type (
 	// FSM the FSM itself
 	FSM[T, S comparable, U, V any] struct {
 		g         *Graph[T, S, U, V]     // Graph is config of FSM. It should be immutable
-		prevState T                      // Last state
-		currState T                      // Now state
-		currEdge  *Edge[T, S, U, V]      // For advanced usages
+		state     atomic.Value           // *StateSnapshot[T, S, U, V]. Replaced as a whole on every state change
 		callbacks *Callbacks[T, S, U, V] // Callbacks
 		noSync    bool                   // If true, Trigger() and some other methods will not be thread-safe
-		mutex     sync.Mutex             // RW-lock
+		mutex     chanLock               // Serialize state changes. Readers use state snapshot without locking
+
+		mailbox       *mailbox[T, S, U, V] // Asynchronous event queue. Nil if not started
+		mailboxConfig *MailboxConfig       // Config used by next Start()
+		mbMutex       sync.Mutex           // Guard mailbox
+
+		subs     map[*subscriber[T, S]]struct{} // Transition subscribers
+		subMutex sync.RWMutex                   // Guard subs
+
+		waiters   map[*waiter[T]]struct{} // Blocking WaitFor calls
+		waitMutex sync.Mutex              // Guard waiters and state assignment
+
+		clock      Clock                       // Time source of timers. Real clock if nil
+		timers     map[uint64]*scheduled[T, S] // Pending state timeouts and scheduled events
+		timerSeq   uint64                      // Last timer id
+		timerMutex sync.Mutex                  // Guard timers
+		timerStore TimerStore[T, S]            // Record pending timers to survive restarts. Optional
+		onTimerErr func(error)                 // Receive errors of events triggered by timers
+		timerErrs  []error                     // Errors of TimerStore reported once timerMutex is released
+
+		deferQueue    []*deferredEvent[S] // Events waiting for a state that accepts them
+		onDeferredErr func(error)         // Receive errors of re-dispatched deferred events
+		deferMutex    sync.Mutex          // Guard deferQueue and onDeferredErr
+
+		middlewares []Middleware[T, S, U, V] // Wrap every transition
+		handler     Handler[T, S, U, V]      // transit wrapped by middlewares. Nil if none
+
+		hooks hooks[T, S, U, V] // Callbacks registered by AddXxx, run after callbacks
+
+		invariants    map[T][]*invariant[T, S, U, V] // State -> Predicates checked after entering it
+		invariantMode InvariantMode                  // Off by default
 	}
 
 	// Callbacks do something while eventE is triggering
@@ -22,15 +52,28 @@ type (
 		onEntry           func(*Event[T, S, U, V]) error
 		beforeStateChange func(*Event[T, S, U, V]) error
 		afterStateChange  func(*Event[T, S, U, V]) error
+		onInternal        func(*Event[T, S, U, V]) error // Replace before and after state change on internal transitions
 		onDefer           func(*Event[T, S, U, V], error)
 	}
 
+	// StateSnapshot Consistent view of FSM state at one moment
+	StateSnapshot[T, S comparable, U, V any] struct {
+		Curr    T                 // Now state
+		Prev    T                 // Last state
+		Edge    *Edge[T, S, U, V] // Edge that led to Curr or to the last region move. For advanced usages
+		Regions []T               // State of each orthogonal region if Curr is a fork. Read only
+		Stack   []T               // States saved by push transitions, bottom first. Read only
+	}
+
 	// Event packaging an eventE
 	Event[T, S comparable, U, V any] struct {
-		fSM      *FSM[T, S, U, V]  // Pointer to fSM
-		eventVal S                 // raw input event value
-		args     []interface{}     // Args to pass to callbacks
-		eventE   *Edge[T, S, U, V] // An Edge for advanced access
+		fSM      *FSM[T, S, U, V]    // Pointer to fSM
+		eventVal S                   // raw input event value
+		args     []interface{}       // Args to pass to callbacks
+		eventE   *Edge[T, S, U, V]   // An Edge for advanced access
+		deferred bool                // Event is deferred by current state and queued
+		branches []*Edge[T, S, U, V] // Branches taken through pseudo-states after eventE
+		region   int                 // Index of the orthogonal region eventE moved. -1 if none
 	}
 
 	// VisualGenerator Type of interaction with visualization power pack
@@ -51,86 +94,239 @@ func NewFsm[T, S comparable, U, V any](desc GraphConfig[T, S, U, V], initState T
 
 // NewFsmByG new an FSM by given graph
 func NewFsmByG[T, S comparable, U, V any](g *Graph[T, S, U, V], initState T) *FSM[T, S, U, V] {
-	return &FSM[T, S, U, V]{
-		g:         g,
-		currState: initState,
+	return NewFsmByGWithClock(g, initState, nil)
+}
+
+// NewFsmByGWithClock new an FSM by given graph whose timers use clock from the start. Nil means real clock
+func NewFsmByGWithClock[T, S comparable, U, V any](g *Graph[T, S, U, V], initState T, clock Clock) *FSM[T, S, U, V] {
+	f := &FSM[T, S, U, V]{
+		g:     g,
+		mutex: newChanLock(),
+		clock: clock,
 	}
+	f.state.Store(&StateSnapshot[T, S, U, V]{Curr: initState, Regions: f.regionsOf(initState)})
+	f.armStateTimers()
+	return f
 }
 
 // Trigger To trigger an eventE by eventE value
 // Thread safe if f.noSync == false
-func (f *FSM[T, S, U, V]) Trigger(eventVal S, args ...interface{}) (e *Event[T, S, U, V], err error) {
+func (f *FSM[T, S, U, V]) Trigger(eventVal S, args ...interface{}) (*Event[T, S, U, V], error) {
 
 	if !f.noSync {
 		f.mutex.Lock()
 		defer f.mutex.Unlock()
 	}
 
+	return f.trigger(eventVal, args)
+}
+
+// TryTrigger Same as Trigger, but return BusyErr immediately if another Trigger is in progress
+func (f *FSM[T, S, U, V]) TryTrigger(eventVal S, args ...interface{}) (*Event[T, S, U, V], error) {
+
+	if !f.noSync {
+		if !f.mutex.TryLock() {
+			return nil, &BusyErr{}
+		}
+		defer f.mutex.Unlock()
+	}
+
+	return f.trigger(eventVal, args)
+}
+
+// TriggerTimeout Same as Trigger, but return LockTimeoutErr if the lock can not be acquired within d
+func (f *FSM[T, S, U, V]) TriggerTimeout(d time.Duration, eventVal S, args ...interface{}) (*Event[T, S, U, V], error) {
+
+	if !f.noSync {
+		if !f.mutex.LockTimeout(d) {
+			return nil, &LockTimeoutErr{Timeout: d}
+		}
+		defer f.mutex.Unlock()
+	}
+
+	return f.trigger(eventVal, args)
+}
+
+// trigger Run a transition, then re-dispatch deferred events. Caller must hold the lock
+func (f *FSM[T, S, U, V]) trigger(eventVal S, args []interface{}) (*Event[T, S, U, V], error) {
+	e, err := f.handle(eventVal, args)
+	if err == nil && (e == nil || !e.deferred) {
+		f.dispatchDeferred()
+	}
+	return e, err
+}
+
+// transit Run one transition. Caller must hold the lock
+func (f *FSM[T, S, U, V]) transit(eventVal S, args []interface{}) (e *Event[T, S, U, V], err error) {
+
 	// Initial eventE without toV
 	e = &Event[T, S, U, V]{
 		fSM:      f,
 		eventVal: eventVal,
 		args:     args,
+		region:   -1,
 	}
 
 	// Callback on entry
-	if f.callbacks != nil && f.callbacks.onEntry != nil {
-		err = f.callbacks.onEntry(e)
-		if err != nil {
-			return e, err
-		}
+	if err = f.runHooks(phaseOnEntry, e); err != nil {
+		return e, err
 	}
 
 	defer func() {
 		// Callback on defer
-		if f.callbacks != nil && f.callbacks.onDefer != nil {
-			f.callbacks.onDefer(e, err)
-		}
+		f.runOnDefer(e, err)
 	}()
 
 	// Try to get next one edge
-	edge, err := f.g.NextEdge(f.currState, eventVal)
+	currState := f.CurrState()
+	edge, err := f.g.NextEdge(currState, eventVal)
+	if err != nil {
+		// Events not handled by a fork go to its regions
+		if region, regionEdge := f.regionEdge(eventVal); regionEdge != nil {
+			return f.transitRegion(e, region, regionEdge)
+		}
+		// Keep it until a state accepts it
+		if _, ok := err.(*InvalidEventErr[T, S]); ok && f.g.Defers(currState, eventVal) {
+			f.enqueueDeferred(eventVal, args)
+			e.deferred = true
+			return e, nil
+		}
+		return e, err
+	}
+
+	// Pop returns to the state on top of the stack
+	edge, stack, err := f.stackOf(currState, edge)
 	if err != nil {
 		return e, err
 	}
 
 	// Fill Trigger
 	e.eventE = edge
-	f.currEdge = edge
 
-	// Before state change
-	if f.callbacks != nil && f.callbacks.beforeStateChange != nil {
-		err = f.callbacks.beforeStateChange(e)
-		if err != nil {
+	// Internal transition stays in current state. Only its own action runs
+	if edge.internal {
+		if err = f.runHooks(phaseGuard, e); err != nil {
+			return e, err
+		}
+		if err = f.runHooks(phaseOnInternal, e); err != nil {
 			return e, err
 		}
+		f.publish(e)
+		return e, nil
+	}
+
+	// Junctions are resolved before any callback
+	if err = f.resolve(e, VertexJunction); err != nil {
+		return e, err
+	}
+
+	// Before state change
+	if err = f.runHooks(phaseGuard, e); err != nil {
+		return e, err
+	}
+	if err = f.runHooks(phaseBeforeStateChange, e); err != nil {
+		return e, err
+	}
+
+	// Choices are resolved after leaving current state
+	if err = f.resolve(e, VertexChoice, VertexJunction); err != nil {
+		return e, err
 	}
 
 	// Assign old and new state
-	f.prevState = f.currState
-	f.currState = f.g.VertexByIdx(edge.toV.idx).stateVal
+	before := f.load()
+	toState := e.ToV().stateVal
+	f.setState(currState, toState, edge, f.regionsOf(toState), stack)
 
 	// After state change
-	if f.callbacks != nil && f.callbacks.afterStateChange != nil {
-		err = f.callbacks.afterStateChange(e)
-		if err != nil {
-			return e, err
-		}
+	err = f.runHooks(phaseAfterStateChange, e)
+
+	// Data left by callbacks must agree with the new state
+	if err == nil {
+		err = f.checkInvariants(e, toState, before)
+	}
+
+	// Timeouts are armed last, so a rollback leaves those of the previous state running
+	if !f.rolledBack(err) {
+		f.armStateTimers()
+	}
+	f.wakeWaiters()
+	if err != nil {
+		return e, err
 	}
 
+	// Notify subscribers
+	f.publish(e)
+
 	return e, nil
 }
 
+// resolve Follow branches while the target of e is a pseudo-state of given kinds
+func (f *FSM[T, S, U, V]) resolve(e *Event[T, S, U, V], kinds ...VertexKind) error {
+	// Each pseudo-state is passed at most once, otherwise guards form a loop
+	for i := 0; i <= len(f.g.itoV); i += 1 {
+		v := e.ToV()
+		match := false
+		for _, k := range kinds {
+			if v.kind == k {
+				match = true
+			}
+		}
+		if !match {
+			return nil
+		}
+		b, err := f.g.Branch(v, e)
+		if err != nil {
+			return err
+		}
+		e.branches = append(e.branches, b)
+	}
+	return &InvalidPseudoStateErr[T]{State: e.ToV().stateVal, Reason: "branches form a loop"}
+}
+
 // CanTrigger Whether given eventVal can trigger event
 func (f *FSM[T, S, U, V]) CanTrigger(eventVal S) bool {
 	_, ok := f.PeekState(f.CurrState(), eventVal)
 	return ok
 }
 
+// AvailableEvents Events that current state accepts, in registration order, followed by those only accepted by regions
+// Events that would be deferred are not included
+func (f *FSM[T, S, U, V]) AvailableEvents() []S {
+	snap := f.load()
+	var resp []S
+	seen := make(map[S]struct{})
+	for i, state := range append([]T{snap.Curr}, snap.Regions...) {
+		v := f.g.VertexByState(state)
+		if v == nil || f.g.adj[v.idx] == nil {
+			continue
+		}
+		for _, e := range f.g.adj[v.idx].eList {
+			if _, ok := seen[e.eventVal]; ok || e.branch {
+				continue
+			}
+			if i == 0 {
+				if _, ok := f.PeekState(state, e.eventVal); !ok {
+					continue
+				}
+			} else if edge, err := f.g.NextEdge(state, e.eventVal); err != nil || edge.stackOp != StackNone {
+				continue
+			}
+			seen[e.eventVal] = struct{}{}
+			resp = append(resp, e.eventVal)
+		}
+	}
+	return resp
+}
+
 // PeekState Peek a state by prev state and event
+// Pop transitions are peeked against current state stack
 func (f *FSM[T, S, U, V]) PeekState(state T, eventVal S) (T, bool) {
 	// Try to get next one edge
 	edge, err := f.g.NextEdge(state, eventVal)
+	if err == nil {
+		edge, _, err = f.stackOf(state, edge)
+	}
 	if err != nil {
 		var resp T
 		return resp, false
@@ -141,16 +337,63 @@ func (f *FSM[T, S, U, V]) PeekState(state T, eventVal S) (T, bool) {
 
 // CanMigrate judge if current state can migrate to given toState by one or more step
 func (f *FSM[T, S, U, V]) CanMigrate(toState T) bool {
-	return f.g.HasPathTo(f.currState, toState)
+	return f.g.HasPathTo(f.CurrState(), toState)
+}
+
+// State Get current state, previous state and current edge atomically
+// Thread safe. It never blocks, so it can also be called in callbacks
+func (f *FSM[T, S, U, V]) State() StateSnapshot[T, S, U, V] {
+	return *f.load()
 }
 
+// PrevState Get previous state
 func (f *FSM[T, S, U, V]) PrevState() T {
-	return f.prevState
+	return f.load().Prev
 }
 
 // CurrState Get current state
 func (f *FSM[T, S, U, V]) CurrState() T {
-	return f.currState
+	return f.load().Curr
+}
+
+// load Current snapshot
+func (f *FSM[T, S, U, V]) load() *StateSnapshot[T, S, U, V] {
+	if snap, ok := f.state.Load().(*StateSnapshot[T, S, U, V]); ok {
+		return snap
+	}
+	return &StateSnapshot[T, S, U, V]{}
+}
+
+// setState Publish a new snapshot. Waiters are woken up by wakeWaiters once the transition is kept
+// currEdge == nil keeps the current edge
+func (f *FSM[T, S, U, V]) setState(prevState, currState T, currEdge *Edge[T, S, U, V], regions, stack []T) {
+	f.waitMutex.Lock()
+	defer f.waitMutex.Unlock()
+
+	if currEdge == nil {
+		currEdge = f.load().Edge
+	}
+	f.state.Store(&StateSnapshot[T, S, U, V]{
+		Curr:    currState,
+		Prev:    prevState,
+		Edge:    currEdge,
+		Regions: regions,
+		Stack:   stack,
+	})
+}
+
+// restoreState Put back a snapshot taken before a rejected transition
+func (f *FSM[T, S, U, V]) restoreState(snap *StateSnapshot[T, S, U, V]) {
+	f.waitMutex.Lock()
+	defer f.waitMutex.Unlock()
+	f.state.Store(snap)
+}
+
+// wakeWaiters Wake up waiters satisfied by current state
+func (f *FSM[T, S, U, V]) wakeWaiters() {
+	f.waitMutex.Lock()
+	defer f.waitMutex.Unlock()
+	f.notifyWaiters(f.CurrState())
 }
 
 // OpenVisualization active visualization
@@ -202,6 +445,7 @@ func (f *FSM[T, S, U, V]) getVisualGenerator() VisualGenerator {
 				idx:      v.idx,
 				stateVal: fmt.Sprintf("%v", v.stateVal),
 				storeVal: fmt.Sprintf("%v", v.storeVal),
+				kind:     v.kind,
 			}
 		}
 		for i, v := range og.itoV {
@@ -212,33 +456,55 @@ func (f *FSM[T, S, U, V]) getVisualGenerator() VisualGenerator {
 					eFast: make(map[string][]*Edge[string, string, string, string], 0),
 				}
 			}
+			if f.g.adj[i] == nil {
+				continue
+			}
 			for _, e := range f.g.adj[i].eList {
-				og.adj[i].addE(&Edge[string, string, string, string]{
+				oe := &Edge[string, string, string, string]{
 					fromV:    og.itoV[e.fromV.idx],
 					toV:      og.itoV[e.toV.idx],
 					eventVal: fmt.Sprintf("%v", e.eventVal),
 					storeVal: fmt.Sprintf("%v", e.storeVal),
-				})
+					after:    e.after,
+					wildcard: e.wildcard,
+					internal: e.internal,
+					stackOp:  e.stackOp,
+				}
+				if e.branch {
+					oe.eventVal = ""
+					og.adj[i].addBranch(oe)
+					continue
+				}
+				og.adj[i].addE(oe)
 			}
 		}
-		return &FSM[string, string, string, string]{
-			g:         og,
-			prevState: fmt.Sprintf("%v", f.prevState),
-			currState: fmt.Sprintf("%v", f.currState),
+		snap := f.load()
+		vf := NewFsmByG(og, fmt.Sprintf("%v", snap.Curr))
+		regions := make([]string, 0, len(snap.Regions))
+		for _, r := range snap.Regions {
+			regions = append(regions, fmt.Sprintf("%v", r))
 		}
+		stack := make([]string, 0, len(snap.Stack))
+		for _, s := range snap.Stack {
+			stack = append(stack, fmt.Sprintf("%v", s))
+		}
+		vf.setState(fmt.Sprintf("%v", snap.Prev), fmt.Sprintf("%v", snap.Curr), nil, regions, stack)
+		return vf
 	}
 }
 
 // ForceSetCurrState prevState will be overwritten
-// It will not modify f.currEdge. not recommended
+// It will not modify current edge and state stack. not recommended
 // Thread safe if f.noSync == false
 func (f *FSM[T, S, U, V]) ForceSetCurrState(currState T) {
 	if !f.noSync {
 		f.mutex.Lock()
 		defer f.mutex.Unlock()
 	}
-	f.prevState = f.currState
-	f.currState = currState
+	f.setState(f.CurrState(), currState, nil, f.regionsOf(currState), f.load().Stack)
+	f.wakeWaiters()
+	f.armStateTimers()
+	f.dispatchDeferred()
 }
 
 // FSM Getter And Setter
@@ -252,7 +518,7 @@ func (f *FSM[T, S, U, V]) Callbacks() *Callbacks[T, S, U, V] {
 }
 
 func (f *FSM[T, S, U, V]) CurrEdge() *Edge[T, S, U, V] {
-	return f.currEdge
+	return f.load().Edge
 }
 
 // SetCallbacks custom callbacks
@@ -269,8 +535,26 @@ func (f *FSM[T, S, U, V]) SetNoSync(noSync bool) {
 	f.noSync = noSync
 }
 
+func (f *FSM[T, S, U, V]) MailboxConfig() *MailboxConfig {
+	return f.mailboxConfig
+}
+
+// SetMailboxConfig takes effect on next Start()
+func (f *FSM[T, S, U, V]) SetMailboxConfig(mailboxConfig *MailboxConfig) {
+	f.mailboxConfig = mailboxConfig
+}
+
 // Callbacks Getter And Setter
 
+func (c *Callbacks[T, S, U, V]) OnEntry() func(*Event[T, S, U, V]) error {
+	return c.onEntry
+}
+
+// SetOnEntry called before looking up the edge. Returning an error rejects the event
+func (c *Callbacks[T, S, U, V]) SetOnEntry(onEntry func(*Event[T, S, U, V]) error) {
+	c.onEntry = onEntry
+}
+
 func (c *Callbacks[T, S, U, V]) BeforeStateChange() func(*Event[T, S, U, V]) error {
 	return c.beforeStateChange
 }
@@ -287,6 +571,14 @@ func (c *Callbacks[T, S, U, V]) SetAfterStateChange(afterStateChange func(*Event
 	c.afterStateChange = afterStateChange
 }
 
+func (c *Callbacks[T, S, U, V]) OnInternal() func(*Event[T, S, U, V]) error {
+	return c.onInternal
+}
+
+func (c *Callbacks[T, S, U, V]) SetOnInternal(onInternal func(*Event[T, S, U, V]) error) {
+	c.onInternal = onInternal
+}
+
 func (c *Callbacks[T, S, U, V]) OnDefer() func(*Event[T, S, U, V], error) {
 	return c.onDefer
 }
@@ -315,6 +607,21 @@ func (e *Event[T, S, U, V]) Args() []interface{} {
 	return e.args
 }
 
+// Internal Whether the event ran an internal transition, which does not exit or enter any state
+func (e *Event[T, S, U, V]) Internal() bool {
+	return e.eventE != nil && e.eventE.internal
+}
+
+// Region Index of the orthogonal region moved by the event. -1 if the event moved the FSM itself
+func (e *Event[T, S, U, V]) Region() int {
+	return e.region
+}
+
+// Deferred Whether the event was queued instead of triggered
+func (e *Event[T, S, U, V]) Deferred() bool {
+	return e.deferred
+}
+
 func (e *Event[T, S, U, V]) FromV() *Vertex[T, V] {
 	if e.eventE != nil {
 		return e.eventE.fromV
@@ -322,13 +629,22 @@ func (e *Event[T, S, U, V]) FromV() *Vertex[T, V] {
 	return nil
 }
 
+// ToV Target vertex. If eventE leads to a pseudo-state, the vertex resolved by its branches
 func (e *Event[T, S, U, V]) ToV() *Vertex[T, V] {
+	if len(e.branches) > 0 {
+		return e.branches[len(e.branches)-1].toV
+	}
 	if e.eventE != nil {
 		return e.eventE.toV
 	}
 	return nil
 }
 
+// Branches Branches taken through pseudo-states, in order
+func (e *Event[T, S, U, V]) Branches() []*Edge[T, S, U, V] {
+	return e.branches
+}
+
 func (e *Event[T, S, U, V]) FromState() (resp T) {
 	fromV := e.FromV()
 	if fromV != nil {
@@ -5,7 +5,9 @@ import (
 	"gotest.tools/v3/assert"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 )
 
 const (
@@ -499,3 +501,135 @@ func TestFSM_CanMigrate(t *testing.T) {
 		})
 	}
 }
+
+// TestFSM_State_Race Run with -race. Readers must always see a consistent snapshot
+func TestFSM_State_Race(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+	tests := generateNonErrorTests()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				snap := testFSM.State()
+				if snap.Edge != nil {
+					assert.Check(t, snap.Edge.FromV().StateVal() == snap.Prev)
+					assert.Check(t, snap.Edge.ToV().StateVal() == snap.Curr)
+				}
+				_ = testFSM.CurrState()
+				_ = testFSM.PrevState()
+				_ = testFSM.CurrEdge()
+				_ = testFSM.CanTrigger(payEvent)
+				_ = testFSM.CanMigrate(done)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i += 1 {
+		for _, tt := range tests {
+			_, err := testFSM.Trigger(tt.eventName)
+			assert.NilError(t, err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestFSM_TryTrigger(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+
+	// Hold the lock inside afterStateChange
+	block := make(chan struct{})
+	entered := make(chan struct{})
+	testFSM.SetCallbacks(&Callbacks[nodeState, eventVal, edgeVal, nodeVal]{
+		afterStateChange: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+			if e.EventVal() == payEvent {
+				close(entered)
+				<-block
+			}
+			return nil
+		},
+	})
+	go func() {
+		_, _ = testFSM.Trigger(payEvent)
+	}()
+	<-entered
+
+	_, err := testFSM.TryTrigger(deliverEvent)
+	_, ok := err.(*BusyErr)
+	assert.Assert(t, ok)
+
+	_, err = testFSM.TriggerTimeout(10*time.Millisecond, deliverEvent)
+	_, ok = err.(*LockTimeoutErr)
+	assert.Assert(t, ok)
+
+	close(block)
+	e, err := testFSM.TriggerTimeout(time.Second, deliverEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, e.ToState(), nodeState(delivering))
+}
+
+func TestFSM_Internal(t *testing.T) {
+
+	const retryEvent = "retryEvent"
+	fac := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+		DescList: append([]*DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+			{
+				EventVal:  retryEvent,
+				FromState: []nodeState{paid},
+				ToState:   paid,
+				Internal:  true,
+			},
+		}, descFac.DescList...),
+	}
+	testFSM, err := NewFsm[nodeState, eventVal, edgeVal, nodeVal](fac, initial)
+	assert.NilError(t, err)
+
+	stateChanges, retries := 0, 0
+	cb := &Callbacks[nodeState, eventVal, edgeVal, nodeVal]{}
+	cb.SetBeforeStateChange(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		stateChanges += 1
+		return nil
+	})
+	cb.SetOnInternal(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		retries += 1
+		return nil
+	})
+	testFSM.SetCallbacks(cb)
+
+	_, err = testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	for i := 0; i < 3; i += 1 {
+		e, err := testFSM.Trigger(retryEvent)
+		assert.NilError(t, err)
+		assert.Assert(t, e.Internal())
+		assert.Equal(t, e.ToState(), nodeState(paid))
+	}
+	assert.Equal(t, stateChanges, 1)
+	assert.Equal(t, retries, 3)
+
+	// History is not corrupted
+	assert.Equal(t, testFSM.PrevState(), nodeState(initial))
+	assert.Equal(t, testFSM.CurrEdge().EventVal(), eventVal(payEvent))
+
+	t.Run("invalid internal", func(t *testing.T) {
+		bad := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+			DescList: []*DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+				{EventVal: retryEvent, FromState: []nodeState{paid}, ToState: done, Internal: true},
+			},
+		}
+		_, err := bad.NewG()
+		_, ok := err.(*InvalidInternalTransitionErr[nodeState, eventVal])
+		assert.Assert(t, ok)
+	})
+}
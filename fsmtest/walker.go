@@ -0,0 +1,267 @@
+// Package fsmtest drives an FSM with random or fuzzed events and checks invariants after every step
+package fsmtest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	fsm "github.com/kiexu/go-generic-fsm"
+)
+
+const defaultSteps = 100
+
+type (
+	// Invariant Checked after every step with the result of Trigger. A non-nil error fails the walk
+	Invariant[T, S comparable, U, V any] func(f *fsm.FSM[T, S, U, V], e *fsm.Event[T, S, U, V], err error) error
+
+	// Walker Fire events against FSMs built by newFSM
+	Walker[T, S comparable, U, V any] struct {
+		newFSM      func() (*fsm.FSM[T, S, U, V], error)
+		invariants  []*namedInvariant[T, S, U, V]
+		alphabet    []S     // Every event that may be fired. Default all events of the graph
+		invalidRate float64 // Probability to pick from alphabet instead of events accepted by current state
+		steps       int     // Events per walk
+	}
+
+	namedInvariant[T, S comparable, U, V any] struct {
+		name string
+		fn   Invariant[T, S, U, V]
+	}
+
+	// Failure A sequence breaking an invariant, shrunk as far as it still breaks the same one
+	Failure[S comparable] struct {
+		Seed      int64 // Seed of the walk. Zero for Replay and fuzzing
+		Events    []S   // Events from a new FSM, the last one makes it fail
+		Invariant string
+		Err       error
+	}
+
+	// chooser Pick the next event from those accepted by current state and from the alphabet
+	chooser[S comparable] func(available, alphabet []S) (S, bool)
+)
+
+// NewWalker New a Walker. newFSM is called once per walk and must return an FSM in its initial state
+func NewWalker[T, S comparable, U, V any](newFSM func() (*fsm.FSM[T, S, U, V], error)) *Walker[T, S, U, V] {
+	return &Walker[T, S, U, V]{
+		newFSM: newFSM,
+		steps:  defaultSteps,
+	}
+}
+
+// AddInvariant Register an invariant. Invariants are checked in registration order
+func (w *Walker[T, S, U, V]) AddInvariant(name string, fn Invariant[T, S, U, V]) *Walker[T, S, U, V] {
+	w.invariants = append(w.invariants, &namedInvariant[T, S, U, V]{name: name, fn: fn})
+	return w
+}
+
+// Walk One random walk. The same seed always fires the same events
+// Return a *Failure with the shrunk sequence if an invariant is broken or Trigger panics
+func (w *Walker[T, S, U, V]) Walk(seed int64) error {
+	r := rand.New(rand.NewSource(seed))
+	events, fail, err := w.run(w.steps, func(available, alphabet []S) (s S, ok bool) {
+		if len(alphabet) > 0 && w.invalidRate > 0 && (len(available) == 0 || r.Float64() < w.invalidRate) {
+			return alphabet[r.Intn(len(alphabet))], true
+		}
+		if len(available) == 0 {
+			return s, false
+		}
+		return available[r.Intn(len(available))], true
+	})
+	if err != nil {
+		return err
+	}
+	if fail == nil {
+		return nil
+	}
+	fail = w.shrink(events, fail)
+	fail.Seed = seed
+	return fail
+}
+
+// Replay Fire events in order on a new FSM
+// Return a *Failure if an invariant is broken or Trigger panics. Errors of Trigger are only passed to invariants
+func (w *Walker[T, S, U, V]) Replay(events []S) error {
+	i := 0
+	_, fail, err := w.run(len(events), func(_, _ []S) (S, bool) {
+		i += 1
+		return events[i-1], true
+	})
+	if err != nil {
+		return err
+	}
+	if fail == nil {
+		return nil
+	}
+	return fail
+}
+
+// ReplayBytes Decode data into events while replaying them
+// Each byte picks one event accepted by current state. If invalid events are enabled,
+// bytes with the high bit set pick from the whole alphabet instead
+func (w *Walker[T, S, U, V]) ReplayBytes(data []byte) error {
+	i := 0
+	events, fail, err := w.run(len(data), func(available, alphabet []S) (s S, ok bool) {
+		b := data[i]
+		i += 1
+		if len(alphabet) > 0 && w.invalidRate > 0 && (len(available) == 0 || b&0x80 != 0) {
+			return alphabet[int(b&0x7f)%len(alphabet)], true
+		}
+		if len(available) == 0 {
+			return s, false
+		}
+		return available[int(b&0x7f)%len(available)], true
+	})
+	if err != nil {
+		return err
+	}
+	if fail == nil {
+		return nil
+	}
+	return w.shrink(events, fail)
+}
+
+// Run Walk once per seed and fail t on the first broken invariant
+func (w *Walker[T, S, U, V]) Run(t testing.TB, seeds ...int64) {
+	t.Helper()
+	for _, seed := range seeds {
+		if err := w.Walk(seed); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Fuzz Run ReplayBytes on every input of the fuzzing engine
+// Seed corpus entries are added by the caller with f.Add([]byte{...})
+func (w *Walker[T, S, U, V]) Fuzz(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := w.ReplayBytes(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// run Fire at most steps events picked by next on a new FSM
+// Return the fired events and the failure if any
+func (w *Walker[T, S, U, V]) run(steps int, next chooser[S]) ([]S, *Failure[S], error) {
+	f, err := w.newFSM()
+	if err != nil {
+		return nil, nil, err
+	}
+	alphabet := w.alphabet
+	if alphabet == nil {
+		alphabet = graphEvents(f.G())
+	}
+
+	var events []S
+	for i := 0; i < steps; i += 1 {
+		ev, ok := next(f.AvailableEvents(), alphabet)
+		if !ok {
+			break
+		}
+		events = append(events, ev)
+		if fail := w.step(f, ev); fail != nil {
+			fail.Events = events
+			return events, fail, nil
+		}
+	}
+	return events, nil, nil
+}
+
+// step Trigger ev then check every invariant
+func (w *Walker[T, S, U, V]) step(f *fsm.FSM[T, S, U, V], ev S) (fail *Failure[S]) {
+	defer func() {
+		if r := recover(); r != nil {
+			fail = &Failure[S]{Err: fmt.Errorf("panic: %v", r)}
+		}
+	}()
+	e, err := f.Trigger(ev)
+	for _, inv := range w.invariants {
+		if invErr := inv.fn(f, e, err); invErr != nil {
+			return &Failure[S]{Invariant: inv.name, Err: invErr}
+		}
+	}
+	return nil
+}
+
+// shrink Remove chunks of events, halving the chunk size, as long as the same invariant still breaks
+func (w *Walker[T, S, U, V]) shrink(events []S, fail *Failure[S]) *Failure[S] {
+	curr := events
+	for chunk := len(curr) / 2; chunk >= 1; chunk /= 2 {
+		for i := 0; i+chunk <= len(curr); {
+			candidate := append(append([]S{}, curr[:i]...), curr[i+chunk:]...)
+			j := 0
+			_, f, err := w.run(len(candidate), func(_, _ []S) (S, bool) {
+				j += 1
+				return candidate[j-1], true
+			})
+			if err == nil && f != nil && f.Invariant == fail.Invariant {
+				fail, curr = f, f.Events
+				continue
+			}
+			i += chunk
+		}
+	}
+	return fail
+}
+
+// graphEvents Distinct event values of g in edge registration order
+func graphEvents[T, S comparable, U, V any](g *fsm.Graph[T, S, U, V]) []S {
+	var resp []S
+	seen := make(map[S]struct{})
+	for _, c := range g.Adj() {
+		if c == nil {
+			continue
+		}
+		for _, e := range c.EList() {
+			if _, ok := seen[e.EventVal()]; ok || e.Branch() {
+				continue
+			}
+			seen[e.EventVal()] = struct{}{}
+			resp = append(resp, e.EventVal())
+		}
+	}
+	return resp
+}
+
+func (f *Failure[S]) Error() string {
+	if f.Invariant == "" {
+		return fmt.Sprintf("events %v: %v", f.Events, f.Err)
+	}
+	return fmt.Sprintf("invariant %s broken after events %v: %v", f.Invariant, f.Events, f.Err)
+}
+
+// Unwrap support errors.Is and errors.As
+func (f *Failure[S]) Unwrap() error {
+	return f.Err
+}
+
+func (w *Walker[T, S, U, V]) Alphabet() []S {
+	return w.alphabet
+}
+
+// SetAlphabet Restrict or extend the events that may be fired, e.g. with events unknown to the graph
+func (w *Walker[T, S, U, V]) SetAlphabet(alphabet ...S) *Walker[T, S, U, V] {
+	w.alphabet = alphabet
+	return w
+}
+
+func (w *Walker[T, S, U, V]) InvalidRate() float64 {
+	return w.invalidRate
+}
+
+// SetInvalidRate Probability in [0, 1] to fire an event from the whole alphabet, which current state may reject
+func (w *Walker[T, S, U, V]) SetInvalidRate(invalidRate float64) *Walker[T, S, U, V] {
+	w.invalidRate = invalidRate
+	return w
+}
+
+func (w *Walker[T, S, U, V]) Steps() int {
+	return w.steps
+}
+
+// SetSteps Events per walk. Default 100
+func (w *Walker[T, S, U, V]) SetSteps(steps int) *Walker[T, S, U, V] {
+	w.steps = steps
+	return w
+}
@@ -0,0 +1,145 @@
+package fsmtest
+
+import (
+	"errors"
+	"fmt"
+	"gotest.tools/v3/assert"
+	"testing"
+
+	fsm "github.com/kiexu/go-generic-fsm"
+)
+
+type orderFSM = fsm.FSM[string, string, string, fsm.NA]
+
+var orderFac = &fsm.DefConfig[string, string, string, fsm.NA]{
+	DescList: []*fsm.DescCell[string, string, string, fsm.NA]{
+		{EventVal: "pay", FromState: []string{"idle"}, ToState: "paid"},
+		{EventVal: "ship", FromState: []string{"paid"}, ToState: "shipped"},
+		{EventVal: "deliver", FromState: []string{"shipped"}, ToState: "delivered"},
+		{EventVal: "cancel", FromState: []string{"paid", "shipped"}, ToState: "canceled"},
+		{EventVal: "reset", FromState: []string{"delivered", "canceled"}, ToState: "idle"},
+	},
+}
+
+// newOrder An order charged on pay. Cancel after shipping forgets the refund
+func newOrder(charged *bool) func() (*orderFSM, error) {
+	return func() (*orderFSM, error) {
+		f, err := fsm.NewFsm[string, string, string, fsm.NA](orderFac, "idle")
+		if err != nil {
+			return nil, err
+		}
+		*charged = false
+		f.AddAfterStateChange(func(e *fsm.Event[string, string, string, fsm.NA]) error {
+			switch {
+			case e.EventVal() == "pay":
+				*charged = true
+			case e.EventVal() == "cancel" && e.FromState() == "paid":
+				*charged = false
+			case e.EventVal() == "reset":
+				*charged = false
+			}
+			return nil
+		}, 0)
+		return f, nil
+	}
+}
+
+func refunded(charged *bool) Invariant[string, string, string, fsm.NA] {
+	return func(f *orderFSM, _ *fsm.Event[string, string, string, fsm.NA], _ error) error {
+		if f.CurrState() == "canceled" && *charged {
+			return errors.New("canceled order is still charged")
+		}
+		return nil
+	}
+}
+
+func TestWalker_Walk(t *testing.T) {
+
+	charged := false
+	w := NewWalker(newOrder(&charged)).AddInvariant("refunded", refunded(&charged))
+
+	var fail *Failure[string]
+	for seed := int64(0); seed < 20 && fail == nil; seed += 1 {
+		err := w.Walk(seed)
+		if err != nil {
+			assert.Assert(t, errors.As(err, &fail))
+			assert.Equal(t, fail.Seed, seed)
+		}
+	}
+	assert.Assert(t, fail != nil)
+	assert.Equal(t, fail.Invariant, "refunded")
+	assert.DeepEqual(t, fail.Events, []string{"pay", "ship", "cancel"})
+
+	// Same seed, same walk
+	again := w.Walk(fail.Seed)
+	assert.Equal(t, again.Error(), fail.Error())
+
+	assert.NilError(t, w.Replay([]string{"pay", "cancel", "reset", "pay", "ship", "deliver"}))
+}
+
+func TestWalker_Invalid(t *testing.T) {
+
+	charged := false
+	w := NewWalker(newOrder(&charged)).
+		SetAlphabet("pay", "ship", "refund").
+		SetInvalidRate(0.5).
+		SetSteps(30).
+		AddInvariant("only invalid events fail", func(_ *orderFSM, _ *fsm.Event[string, string, string, fsm.NA], err error) error {
+			var invalid *fsm.InvalidEventErr[string, string]
+			if err != nil && !errors.As(err, &invalid) {
+				return err
+			}
+			return nil
+		})
+	w.Run(t, 1, 2, 3)
+
+	// Panics of callbacks are failures
+	panicky := NewWalker(func() (*orderFSM, error) {
+		f, err := newOrder(&charged)()
+		if err != nil {
+			return nil, err
+		}
+		f.AddAfterStateChange(func(e *fsm.Event[string, string, string, fsm.NA]) error {
+			if e.ToState() == "delivered" {
+				panic("boom")
+			}
+			return nil
+		}, 0)
+		return f, nil
+	})
+	err := panicky.Replay([]string{"pay", "cancel", "reset", "pay", "ship", "deliver"})
+	var fail *Failure[string]
+	assert.Assert(t, errors.As(err, &fail))
+	assert.Equal(t, fail.Invariant, "")
+	assert.DeepEqual(t, fail.Events, []string{"pay", "cancel", "reset", "pay", "ship", "deliver"})
+}
+
+func TestWalker_ReplayBytes(t *testing.T) {
+
+	charged := false
+	w := NewWalker(newOrder(&charged)).AddInvariant("refunded", refunded(&charged))
+
+	// idle accepts pay, paid accepts ship then cancel, shipped accepts deliver then cancel
+	err := w.ReplayBytes([]byte{0, 0, 1, 5, 0})
+	var fail *Failure[string]
+	assert.Assert(t, errors.As(err, &fail))
+	assert.DeepEqual(t, fail.Events, []string{"pay", "ship", "cancel"})
+
+	assert.NilError(t, w.ReplayBytes([]byte{0, 1, 0, 0, 0, 0}))
+}
+
+func FuzzWalker(f *testing.F) {
+
+	charged := false
+	w := NewWalker(newOrder(&charged)).
+		SetInvalidRate(0.1).
+		AddInvariant("known state", func(f *orderFSM, _ *fsm.Event[string, string, string, fsm.NA], _ error) error {
+			if f.G().VertexByState(f.CurrState()) == nil {
+				return fmt.Errorf("unknown state %s", f.CurrState())
+			}
+			return nil
+		})
+	f.Add([]byte{0, 0, 0, 0})
+	f.Add([]byte{0, 1, 0, 0x80, 0x81})
+	w.Fuzz(f)
+}
@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/kiexu/go-generic-collection v0.3.1
+	gopkg.in/yaml.v3 v3.0.1
 	gotest.tools/v3 v3.3.0
 )
 
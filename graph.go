@@ -20,6 +20,15 @@ type (
 		adj  []*EdgeCollection[T, S, U, V] // Adjacency table
 		stoV map[T]*Vertex[T, V]           // State value -> Vertex
 		itoV []*Vertex[T, V]               // State idx -> Vertex
+
+		deferred map[T]map[S]struct{} // State value -> Events deferred in this state
+		joins    []*join[T, V]        // Join pseudo-states
+	}
+
+	// join Leave a fork once every region is in one of sources
+	join[T comparable, V any] struct {
+		v       *Vertex[T, V]
+		sources []*Vertex[T, V]
 	}
 
 	// pathWrapper a recursion helper
@@ -45,14 +54,32 @@ func (g *Graph[T, S, U, V]) NextEdges(fromState T, eventName S) ([]*Edge[T, S, U
 	if fromV == nil {
 		return nil, &StateNotExistErr[T]{State: fromState}
 	}
-	eList := g.adj[fromV.idx].EdgeByEventVal(eventName)
+	var eList []*Edge[T, S, U, V]
+	if g.adj[fromV.idx] != nil {
+		eList = g.adj[fromV.idx].EdgeByEventVal(eventName)
+	}
 	if len(eList) == 0 {
 		return nil, &InvalidEventErr[T, S]{State: fromState, Event: eventName}
 	}
 	return eList, nil
 }
 
+// PopEdge Query the pop edge by state and event name which returns to top
+func (g *Graph[T, S, U, V]) PopEdge(fromState T, eventName S, top T) (*Edge[T, S, U, V], error) {
+	edges, err := g.NextEdges(fromState, eventName)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range edges {
+		if e.stackOp == StackPop && e.toV.stateVal == top {
+			return e, nil
+		}
+	}
+	return nil, &InvalidEventErr[T, S]{State: fromState, Event: eventName}
+}
+
 // HasPathTo Find if one state can be migrated to another state
+// Pop edges lead to every state that may be pushed, so the result does not depend on the stack
 func (g *Graph[T, S, U, V]) HasPathTo(fromState T, toState T) bool {
 	resp, err := g.pathTo(fromState, toState, PathOptNa)
 	if err != nil {
@@ -174,6 +201,114 @@ func (g *Graph[T, S, U, V]) pathDfs(st *stack.Stack[int], toIdx int, visited *vi
 	return
 }
 
+// addE Add an edge to the collection of its from vertex
+func (g *Graph[T, S, U, V]) addE(e *Edge[T, S, U, V]) {
+	fromIdx := e.fromV.idx
+	if g.adj[fromIdx] == nil {
+		g.adj[fromIdx] = &EdgeCollection[T, S, U, V]{
+			eList: make([]*Edge[T, S, U, V], 0),
+			eFast: make(map[S][]*Edge[T, S, U, V]),
+		}
+	}
+	g.adj[fromIdx].addE(e)
+}
+
+// addBranch Add a branch to the collection of its pseudo-state
+func (g *Graph[T, S, U, V]) addBranch(e *Edge[T, S, U, V]) {
+	fromIdx := e.fromV.idx
+	if g.adj[fromIdx] == nil {
+		g.adj[fromIdx] = &EdgeCollection[T, S, U, V]{
+			eList: make([]*Edge[T, S, U, V], 0),
+			eFast: make(map[S][]*Edge[T, S, U, V]),
+		}
+	}
+	g.adj[fromIdx].addBranch(e)
+}
+
+// Branch Pick the first branch of pseudo-state v whose guard passes
+func (g *Graph[T, S, U, V]) Branch(v *Vertex[T, V], e *Event[T, S, U, V]) (*Edge[T, S, U, V], error) {
+	if g.adj[v.idx] != nil {
+		for _, b := range g.adj[v.idx].eList {
+			if b.branch && (b.guard == nil || b.guard(e)) {
+				return b, nil
+			}
+		}
+	}
+	return nil, &NoBranchErr[T]{State: v.stateVal}
+}
+
+// Regions Initial states of the orthogonal regions of fork v. Nil if v is not a fork
+func (g *Graph[T, S, U, V]) Regions(v *Vertex[T, V]) []T {
+	if v.kind != VertexFork || g.adj[v.idx] == nil {
+		return nil
+	}
+	var resp []T
+	for _, b := range g.adj[v.idx].eList {
+		if b.branch {
+			resp = append(resp, b.toV.stateVal)
+		}
+	}
+	return resp
+}
+
+// Join Find a join satisfied by region states after region moved
+// Only a region entering one of the sources fires a join.
+// Return the branch from that region into the join and the branch out of the join. Nil if no join is satisfied
+func (g *Graph[T, S, U, V]) Join(regions []T, region int) []*Edge[T, S, U, V] {
+	moved := g.VertexByState(regions[region])
+	if moved == nil || g.adj[moved.idx] == nil {
+		return nil
+	}
+	for _, j := range g.joins {
+		var in *Edge[T, S, U, V]
+		satisfied := false
+		for _, sv := range j.sources {
+			if sv == moved {
+				satisfied = true
+				break
+			}
+		}
+		for _, sv := range j.sources {
+			if !matchOne(regions, sv.stateVal) {
+				satisfied = false
+				break
+			}
+		}
+		if !satisfied {
+			continue
+		}
+		for _, e := range g.adj[moved.idx].eList {
+			if e.branch && e.toV == j.v {
+				in = e
+			}
+		}
+		if in == nil {
+			continue
+		}
+		return []*Edge[T, S, U, V]{in, g.adj[j.v.idx].eList[0]}
+	}
+	return nil
+}
+
+// Defers Whether state defers eventVal
+func (g *Graph[T, S, U, V]) Defers(stateVal T, eventVal S) bool {
+	_, ok := g.deferred[stateVal][eventVal]
+	return ok
+}
+
+// SetDefers declare events deferred by state
+func (g *Graph[T, S, U, V]) SetDefers(stateVal T, eventVals ...S) {
+	if g.deferred == nil {
+		g.deferred = make(map[T]map[S]struct{})
+	}
+	if g.deferred[stateVal] == nil {
+		g.deferred[stateVal] = make(map[S]struct{})
+	}
+	for _, ev := range eventVals {
+		g.deferred[stateVal][ev] = struct{}{}
+	}
+}
+
 // VertexByState Get vertex by state value
 func (g *Graph[T, S, U, V]) VertexByState(stateVal T) *Vertex[T, V] {
 	return g.stoV[stateVal]
@@ -427,3 +427,72 @@ func TestGraph_AllPathEdgesTo_0(t *testing.T) {
 		})
 	}
 }
+
+func TestGraph_Wildcard(t *testing.T) {
+
+	const abortEvent = "abortEvent"
+	fac := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+		DescList: append([]*DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+			{
+				EventVal: abortEvent,
+				FromAny:  true,
+				Except:   []nodeState{initial},
+				ToState:  canceled,
+			},
+			{
+				EventVal: cancelEvent, // Shadowed by specific cancelEvent from paid and delivering
+				FromAny:  true,
+				ToState:  done,
+			},
+		}, descFac.DescList...),
+	}
+	g, err := fac.NewG()
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name    string
+		from    nodeState
+		event   eventVal
+		want    nodeState
+		wantErr bool
+	}{
+		{name: "abort from done", from: done, event: abortEvent, want: canceled},
+		{name: "abort from canceled", from: canceled, event: abortEvent, want: canceled},
+		{name: "abort from initial", from: initial, event: abortEvent, wantErr: true},
+		{name: "specific cancel", from: paid, event: cancelEvent, want: canceled},
+		{name: "wildcard cancel", from: initial, event: cancelEvent, want: done},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edge, err := g.NextEdge(tt.from, tt.event)
+			if tt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, edge.ToV().StateVal(), tt.want)
+		})
+	}
+
+	// Wildcard edges are real paths
+	assert.Assert(t, g.HasPathTo(initial, done))
+	direct := false
+	for _, events := range wantEdgeEventTestFormatter(g.AllPathEdgesTo(initial, done)) {
+		if len(events) == 1 && events[0] == cancelEvent {
+			direct = true
+		}
+	}
+	assert.Assert(t, direct)
+
+	t.Run("duplicate wildcard", func(t *testing.T) {
+		dup := &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+			DescList: append([]*DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+				{EventVal: abortEvent, FromAny: true, ToState: canceled},
+				{EventVal: abortEvent, FromAny: true, ToState: initial},
+			}, descFac.DescList...),
+		}
+		_, err := dup.NewG()
+		_, ok := err.(*DuplicateStateAndEventErr[nodeState, eventVal])
+		assert.Assert(t, ok)
+	})
+}
@@ -0,0 +1,223 @@
+package fsm
+
+import (
+	"errors"
+	"sync"
+)
+
+// HookErrPolicy How errors of hooks registered to one phase are handled
+type HookErrPolicy int
+
+const (
+	HookStopOnFirst HookErrPolicy = iota // Skip the remaining hooks and return the first error
+	HookCollectAll                       // Run every hook and return HookErrs if any failed. GuardRejectedErr still stops at once
+)
+
+// hookPhase Phases accepting error-returning hooks
+type hookPhase int
+
+const (
+	phaseOnEntry hookPhase = iota
+	phaseBeforeStateChange
+	phaseAfterStateChange
+	phaseOnInternal
+	phaseGuard // Before Callbacks and hooks of phaseBeforeStateChange or phaseOnInternal. Used by TypedFSM
+	phaseCount
+)
+
+// hookAborter Errors that skip the remaining hooks of a phase whatever HookErrPolicy is
+type hookAborter interface {
+	abortHooks()
+}
+
+type (
+	// HookHandle Returned by FSM.AddXxx to remove the hook later
+	HookHandle struct {
+		once   sync.Once
+		remove func()
+	}
+
+	// hook One registered function
+	hook[F any] struct {
+		fn       F
+		priority int
+	}
+
+	// hooks Hooks of every phase. Slices are replaced on change, so running hooks may remove themselves
+	hooks[T, S comparable, U, V any] struct {
+		phases    [phaseCount][]*hook[func(*Event[T, S, U, V]) error]
+		onDefer   []*hook[func(*Event[T, S, U, V], error)]
+		errPolicy HookErrPolicy
+		mutex     sync.RWMutex
+	}
+)
+
+// Remove the hook. Safe to call more than once and from inside the hook
+func (h *HookHandle) Remove() {
+	h.once.Do(h.remove)
+}
+
+// AddOnEntry register fn called before looking up the edge
+// Hooks run after Callbacks, by priority from high to low, then by registration order
+func (f *FSM[T, S, U, V]) AddOnEntry(fn func(*Event[T, S, U, V]) error, priority int) *HookHandle {
+	return f.addHook(phaseOnEntry, fn, priority)
+}
+
+// AddBeforeStateChange register fn called before leaving current state
+func (f *FSM[T, S, U, V]) AddBeforeStateChange(fn func(*Event[T, S, U, V]) error, priority int) *HookHandle {
+	return f.addHook(phaseBeforeStateChange, fn, priority)
+}
+
+// AddAfterStateChange register fn called after entering new state
+func (f *FSM[T, S, U, V]) AddAfterStateChange(fn func(*Event[T, S, U, V]) error, priority int) *HookHandle {
+	return f.addHook(phaseAfterStateChange, fn, priority)
+}
+
+// AddOnInternal register fn called on internal transitions
+func (f *FSM[T, S, U, V]) AddOnInternal(fn func(*Event[T, S, U, V]) error, priority int) *HookHandle {
+	return f.addHook(phaseOnInternal, fn, priority)
+}
+
+// AddOnDefer register fn called when a transition ends, with its error
+func (f *FSM[T, S, U, V]) AddOnDefer(fn func(*Event[T, S, U, V], error), priority int) *HookHandle {
+	f.hooks.mutex.Lock()
+	defer f.hooks.mutex.Unlock()
+	h := &hook[func(*Event[T, S, U, V], error)]{fn: fn, priority: priority}
+	f.hooks.onDefer = insertHook(f.hooks.onDefer, h)
+	return &HookHandle{remove: func() {
+		f.hooks.mutex.Lock()
+		defer f.hooks.mutex.Unlock()
+		f.hooks.onDefer = removeHook(f.hooks.onDefer, h)
+	}}
+}
+
+// addGuard Register fn run before any Callbacks or hooks once the edge is known
+func (f *FSM[T, S, U, V]) addGuard(fn func(*Event[T, S, U, V]) error) *HookHandle {
+	return f.addHook(phaseGuard, fn, 0)
+}
+
+// addHook Register fn to phase
+func (f *FSM[T, S, U, V]) addHook(phase hookPhase, fn func(*Event[T, S, U, V]) error, priority int) *HookHandle {
+	f.hooks.mutex.Lock()
+	defer f.hooks.mutex.Unlock()
+	h := &hook[func(*Event[T, S, U, V]) error]{fn: fn, priority: priority}
+	f.hooks.phases[phase] = insertHook(f.hooks.phases[phase], h)
+	return &HookHandle{remove: func() {
+		f.hooks.mutex.Lock()
+		defer f.hooks.mutex.Unlock()
+		f.hooks.phases[phase] = removeHook(f.hooks.phases[phase], h)
+	}}
+}
+
+// runHooks Run Callbacks then hooks of phase according to error policy
+func (f *FSM[T, S, U, V]) runHooks(phase hookPhase, e *Event[T, S, U, V]) error {
+	f.hooks.mutex.RLock()
+	list := f.hooks.phases[phase]
+	policy := f.hooks.errPolicy
+	f.hooks.mutex.RUnlock()
+
+	var errs []error
+	var aborted error // Returned alone, errors collected before it are dropped
+	run := func(fn func(*Event[T, S, U, V]) error) bool {
+		if fn == nil {
+			return true
+		}
+		if err := fn(e); err != nil {
+			var aborter hookAborter
+			if errors.As(err, &aborter) {
+				aborted = err
+				return false
+			}
+			errs = append(errs, err)
+			return policy == HookCollectAll
+		}
+		return true
+	}
+
+	if run(f.legacyHook(phase)) {
+		for _, h := range list {
+			if !run(h.fn) {
+				break
+			}
+		}
+	}
+
+	switch {
+	case aborted != nil:
+		return aborted
+	case len(errs) == 0:
+		return nil
+	case policy == HookStopOnFirst:
+		return errs[0]
+	default:
+		return &HookErrs{Errs: errs}
+	}
+}
+
+// runOnDefer Run Callbacks then hooks called when a transition ends
+func (f *FSM[T, S, U, V]) runOnDefer(e *Event[T, S, U, V], err error) {
+	f.hooks.mutex.RLock()
+	list := f.hooks.onDefer
+	f.hooks.mutex.RUnlock()
+
+	if f.callbacks != nil && f.callbacks.onDefer != nil {
+		f.callbacks.onDefer(e, err)
+	}
+	for _, h := range list {
+		h.fn(e, err)
+	}
+}
+
+// legacyHook Function of phase in Callbacks. Nil if not set
+func (f *FSM[T, S, U, V]) legacyHook(phase hookPhase) func(*Event[T, S, U, V]) error {
+	if f.callbacks == nil {
+		return nil
+	}
+	switch phase {
+	case phaseOnEntry:
+		return f.callbacks.onEntry
+	case phaseBeforeStateChange:
+		return f.callbacks.beforeStateChange
+	case phaseAfterStateChange:
+		return f.callbacks.afterStateChange
+	case phaseOnInternal:
+		return f.callbacks.onInternal
+	}
+	return nil
+}
+
+func (f *FSM[T, S, U, V]) HookErrPolicy() HookErrPolicy {
+	f.hooks.mutex.RLock()
+	defer f.hooks.mutex.RUnlock()
+	return f.hooks.errPolicy
+}
+
+// SetHookErrPolicy applies to Callbacks and hooks of every phase
+func (f *FSM[T, S, U, V]) SetHookErrPolicy(errPolicy HookErrPolicy) {
+	f.hooks.mutex.Lock()
+	defer f.hooks.mutex.Unlock()
+	f.hooks.errPolicy = errPolicy
+}
+
+// insertHook Copy list with h after every hook of higher or equal priority
+func insertHook[F any](list []*hook[F], h *hook[F]) []*hook[F] {
+	i := len(list)
+	for i > 0 && list[i-1].priority < h.priority {
+		i -= 1
+	}
+	resp := make([]*hook[F], 0, len(list)+1)
+	resp = append(resp, list[:i]...)
+	resp = append(resp, h)
+	return append(resp, list[i:]...)
+}
+
+// removeHook Copy list without h
+func removeHook[F any](list []*hook[F], h *hook[F]) []*hook[F] {
+	resp := make([]*hook[F], 0, len(list))
+	for _, l := range list {
+		if l != h {
+			resp = append(resp, l)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,88 @@
+package fsm
+
+import (
+	"errors"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestFSM_AddHooks(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+
+	var order []string
+	record := func(name string, err error) func(*Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		return func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	cb := &Callbacks[nodeState, eventVal, edgeVal, nodeVal]{}
+	cb.SetBeforeStateChange(record("legacy", nil))
+	testFSM.SetCallbacks(cb)
+
+	testFSM.AddBeforeStateChange(record("low", nil), -1)
+	testFSM.AddBeforeStateChange(record("first", nil), 0)
+	testFSM.AddBeforeStateChange(record("high", nil), 10)
+	second := testFSM.AddBeforeStateChange(record("second", nil), 0)
+	var ended []error
+	testFSM.AddOnDefer(func(e *Event[nodeState, eventVal, edgeVal, nodeVal], err error) {
+		ended = append(ended, err)
+	}, 0)
+
+	_, err := testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, order, []string{"legacy", "high", "first", "second", "low"})
+	assert.Equal(t, len(ended), 1)
+
+	// Removed hooks no longer run
+	second.Remove()
+	second.Remove()
+	order = nil
+	testFSM.ForceSetCurrState(initial)
+	_, err = testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, order, []string{"legacy", "high", "first", "low"})
+}
+
+func TestFSM_HookErrPolicy(t *testing.T) {
+
+	errA, errB := errors.New("a"), errors.New("b")
+	tests := []struct {
+		name   string
+		policy HookErrPolicy
+		runs   int
+		errs   []error
+	}{
+		{name: "stop on first", policy: HookStopOnFirst, runs: 1, errs: []error{errA}},
+		{name: "collect all", policy: HookCollectAll, runs: 3, errs: []error{errA, errB}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+			testFSM.SetHookErrPolicy(tt.policy)
+
+			runs := 0
+			for _, err := range []error{errA, nil, errB} {
+				err := err
+				testFSM.AddAfterStateChange(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+					runs += 1
+					return err
+				}, 0)
+			}
+
+			_, err := testFSM.Trigger(payEvent)
+			assert.Equal(t, runs, tt.runs)
+			if tt.policy == HookStopOnFirst {
+				assert.Equal(t, err, tt.errs[0])
+				return
+			}
+			hookErrs, ok := err.(*HookErrs)
+			assert.Assert(t, ok)
+			assert.Equal(t, len(hookErrs.Errs), len(tt.errs))
+			for i := range tt.errs {
+				assert.Equal(t, hookErrs.Errs[i], tt.errs[i])
+			}
+		})
+	}
+}
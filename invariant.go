@@ -0,0 +1,71 @@
+package fsm
+
+// InvariantMode What Trigger does with state invariants
+type InvariantMode int
+
+const (
+	InvariantOff      InvariantMode = iota // Production. Invariants are never evaluated
+	InvariantCheck                         // Return InvariantViolationErr and stay in the entered state
+	InvariantRollback                      // Return InvariantViolationErr and restore the state before the transition
+)
+
+// invariant Predicate that must hold while in a state
+type invariant[T, S comparable, U, V any] struct {
+	name string
+	pred func(*Event[T, S, U, V]) bool
+}
+
+// AddInvariant declare a predicate that must hold after entering state
+// pred sees the event that entered the state, its args and whatever user context it captures.
+// Invariants are only evaluated when InvariantMode is not InvariantOff
+func (f *FSM[T, S, U, V]) AddInvariant(state T, name string, pred func(*Event[T, S, U, V]) bool) {
+	if !f.noSync {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+	}
+	if f.invariants == nil {
+		f.invariants = make(map[T][]*invariant[T, S, U, V])
+	}
+	f.invariants[state] = append(f.invariants[state], &invariant[T, S, U, V]{name: name, pred: pred})
+}
+
+// checkInvariants Evaluate invariants of state entered by e. Caller must hold the lock
+// On violation with InvariantRollback, the snapshot before the transition is restored.
+// Data changed by callbacks is not restored. Caller must not have armed timeouts of the entered state
+// nor woken up waiters yet
+func (f *FSM[T, S, U, V]) checkInvariants(e *Event[T, S, U, V], state T, before *StateSnapshot[T, S, U, V]) error {
+	if f.invariantMode == InvariantOff {
+		return nil
+	}
+	for _, inv := range f.invariants[state] {
+		if inv.pred(e) {
+			continue
+		}
+		err := &InvariantViolationErr[T]{State: state, Invariant: inv.name}
+		if f.invariantMode == InvariantRollback {
+			f.restoreState(before)
+			err.RolledBack = true
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FSM[T, S, U, V]) InvariantMode() InvariantMode {
+	return f.invariantMode
+}
+
+// SetInvariantMode Default InvariantOff
+func (f *FSM[T, S, U, V]) SetInvariantMode(invariantMode InvariantMode) {
+	if !f.noSync {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+	}
+	f.invariantMode = invariantMode
+}
+
+// rolledBack Whether err is an invariant violation that restored the previous state
+func (f *FSM[T, S, U, V]) rolledBack(err error) bool {
+	v, ok := err.(*InvariantViolationErr[T])
+	return ok && v.RolledBack
+}
@@ -0,0 +1,129 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+func TestFSM_Invariant(t *testing.T) {
+
+	g, _ := descFac.NewG()
+	f := NewFsmByG(g, initial)
+	charged := 0
+	f.AddAfterStateChange(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		if e.EventVal() == payEvent {
+			charged += 1
+		}
+		return nil
+	}, 0)
+	f.AddInvariant(paid, "charged once", func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+		return charged == 1
+	})
+	f.AddInvariant(delivering, "has courier", func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+		return len(e.Args()) == 1
+	})
+
+	// Off by default
+	charged = 1
+	_, err := f.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, f.CurrState(), nodeState(paid))
+
+	// Check keeps the entered state
+	f.SetInvariantMode(InvariantCheck)
+	_, err = f.Trigger(deliverEvent)
+	var violation *InvariantViolationErr[nodeState]
+	assert.Assert(t, errors.As(err, &violation))
+	assert.Equal(t, violation.Invariant, "has courier")
+	assert.Assert(t, !violation.RolledBack)
+	assert.Equal(t, f.CurrState(), nodeState(delivering))
+
+	// Rollback restores the previous snapshot
+	f.ForceSetCurrState(paid)
+	f.SetInvariantMode(InvariantRollback)
+	before := f.State()
+	_, err = f.Trigger(deliverEvent)
+	assert.Assert(t, errors.As(err, &violation))
+	assert.Assert(t, violation.RolledBack)
+	assert.Equal(t, f.CurrState(), before.Curr)
+	assert.Equal(t, f.PrevState(), before.Prev)
+	assert.Equal(t, f.CurrEdge(), before.Edge)
+
+	_, err = f.Trigger(deliverEvent, "courier")
+	assert.NilError(t, err)
+	assert.Equal(t, f.CurrState(), nodeState(delivering))
+
+	// Invariants hold after a whole round
+	charged = 0
+	for _, ev := range []eventVal{receiveEvent, readyEvent} {
+		_, err = f.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	_, err = f.Trigger(payEvent)
+	assert.NilError(t, err)
+}
+
+func TestFSM_Invariant_RollbackTimers(t *testing.T) {
+
+	store := NewMemTimerStore[nodeState, eventVal]()
+	clock := NewFakeClock(time.Unix(0, 0))
+	f, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	f.SetClock(clock)
+	f.SetTimerStore(store)
+	f.SetInvariantMode(InvariantRollback)
+	f.AddInvariant(delivering, "never", func(*Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+		return false
+	})
+
+	_, err := f.Trigger(payEvent)
+	assert.NilError(t, err)
+	records, _ := store.Load()
+	clock.Advance(29 * time.Second)
+
+	// Rolled back transition keeps the original deadline and records
+	_, err = f.Trigger(deliverEvent)
+	assert.ErrorType(t, err, &InvariantViolationErr[nodeState]{})
+	after, _ := store.Load()
+	assert.DeepEqual(t, after, records)
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, f.CurrState(), nodeState(canceled))
+}
+
+func TestFSM_Invariant_RollbackFirst(t *testing.T) {
+
+	g, _ := descFac.NewG()
+	f := NewFsmByG(g, initial)
+	f.SetInvariantMode(InvariantRollback)
+	ok := false
+	f.AddInvariant(paid, "ok", func(*Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+		return ok
+	})
+
+	got := make(chan nodeState, 1)
+	go func() {
+		s, _ := f.WaitForState(context.Background(), paid)
+		got <- s
+	}()
+	for waiting := 0; waiting == 0; {
+		f.waitMutex.Lock()
+		waiting = len(f.waiters)
+		f.waitMutex.Unlock()
+	}
+
+	// No edge was taken before, and the waiter is not woken up by the rejected state
+	_, err := f.Trigger(payEvent)
+	assert.ErrorType(t, err, &InvariantViolationErr[nodeState]{})
+	assert.Equal(t, f.CurrState(), nodeState(initial))
+	assert.Assert(t, f.CurrEdge() == nil)
+	f.waitMutex.Lock()
+	assert.Equal(t, len(f.waiters), 1)
+	f.waitMutex.Unlock()
+
+	ok = true
+	_, err = f.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, <-got, nodeState(paid))
+}
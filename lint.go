@@ -0,0 +1,102 @@
+package fsm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LintKind Kind of a problem found by Graph.Lint
+type LintKind int
+
+const (
+	LintUnreachable   LintKind = iota // State can not be reached from the initial state
+	LintDeadEnd                       // Regular state without any outgoing transition
+	LintDeferShadowed                 // State defers an event it also handles, so the event is never deferred
+	LintNoElse                        // Every branch of a choice or junction is guarded, NoBranchErr is possible
+)
+
+// LintIssue One problem of a Graph
+type LintIssue[T, S comparable] struct {
+	Kind  LintKind
+	State T
+	Event S // Only for LintDeferShadowed
+}
+
+func (i LintIssue[T, S]) String() string {
+	switch i.Kind {
+	case LintUnreachable:
+		return fmt.Sprintf("state %v is unreachable", i.State)
+	case LintDeadEnd:
+		return fmt.Sprintf("state %v has no outgoing transition", i.State)
+	case LintDeferShadowed:
+		return fmt.Sprintf("state %v defers event %v but also handles it", i.State, i.Event)
+	case LintNoElse:
+		return fmt.Sprintf("pseudo-state %v has no else branch", i.State)
+	}
+	return fmt.Sprintf("unknown issue of state %v", i.State)
+}
+
+// Lint Find suspicious parts of g, ordered by state idx then kind
+// Dead ends are often intended final states, so callers decide which kinds matter
+func (g *Graph[T, S, U, V]) Lint(initState T) ([]*LintIssue[T, S], error) {
+	initV := g.VertexByState(initState)
+	if initV == nil {
+		return nil, &StateNotExistErr[T]{State: initState}
+	}
+
+	// Breadth first over every edge, including branches and joins
+	reached := make([]bool, len(g.itoV))
+	reached[initV.idx] = true
+	queue := []int{initV.idx}
+	for len(queue) > 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		if g.adj[idx] == nil {
+			continue
+		}
+		for _, e := range g.adj[idx].eList {
+			if !reached[e.toV.idx] {
+				reached[e.toV.idx] = true
+				queue = append(queue, e.toV.idx)
+			}
+		}
+	}
+
+	var resp []*LintIssue[T, S]
+	for _, v := range g.itoV {
+		if !reached[v.idx] {
+			resp = append(resp, &LintIssue[T, S]{Kind: LintUnreachable, State: v.stateVal})
+		}
+		var eList []*Edge[T, S, U, V]
+		if g.adj[v.idx] != nil {
+			eList = g.adj[v.idx].eList
+		}
+		if len(eList) == 0 && v.kind == VertexState {
+			resp = append(resp, &LintIssue[T, S]{Kind: LintDeadEnd, State: v.stateVal})
+		}
+		var shadowed []S
+		for ev := range g.deferred[v.stateVal] {
+			if _, err := g.NextEdge(v.stateVal, ev); err == nil {
+				shadowed = append(shadowed, ev)
+			}
+		}
+		sort.Slice(shadowed, func(i, j int) bool {
+			return fmt.Sprintf("%v", shadowed[i]) < fmt.Sprintf("%v", shadowed[j])
+		})
+		for _, ev := range shadowed {
+			resp = append(resp, &LintIssue[T, S]{Kind: LintDeferShadowed, State: v.stateVal, Event: ev})
+		}
+		if v.kind == VertexChoice || v.kind == VertexJunction {
+			hasElse := false
+			for _, e := range eList {
+				if e.branch && e.guard == nil {
+					hasElse = true
+				}
+			}
+			if !hasElse {
+				resp = append(resp, &LintIssue[T, S]{Kind: LintNoElse, State: v.stateVal})
+			}
+		}
+	}
+	return resp, nil
+}
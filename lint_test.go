@@ -0,0 +1,62 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestGraph_Lint(t *testing.T) {
+
+	fac := &DefConfig[string, string, NA, NA]{
+		DescList: []*DescCell[string, string, NA, NA]{
+			{EventVal: "start", FromState: []string{"idle"}, ToState: "check"},
+			{EventVal: "stop", FromState: []string{"running"}, ToState: "idle"},
+			{EventVal: "stop", FromState: []string{"orphan"}, ToState: "idle"},
+		},
+		PseudoMap: map[string]*PseudoDesc[string, string, NA, NA]{
+			"check": {
+				Kind: VertexChoice,
+				Branches: []*BranchDesc[string, string, NA, NA]{
+					{Guard: func(*Event[string, string, NA, NA]) bool { return true }, ToState: "running"},
+					{Guard: func(*Event[string, string, NA, NA]) bool { return false }, ToState: "failed"},
+				},
+			},
+		},
+		DeferMap: map[string][]string{"idle": {"stop", "start"}},
+	}
+	g, err := fac.NewG()
+	assert.NilError(t, err)
+
+	issues, err := g.Lint("idle")
+	assert.NilError(t, err)
+	var got []string
+	for _, i := range issues {
+		got = append(got, i.String())
+	}
+	assert.DeepEqual(t, got, []string{
+		"pseudo-state check has no else branch",
+		"state idle defers event start but also handles it",
+		"state orphan is unreachable",
+		"state failed has no outgoing transition",
+	})
+
+	_, err = g.Lint("unknown")
+	assert.Assert(t, err != nil)
+}
+
+func TestFSM_AvailableEvents(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, paid)
+	assert.DeepEqual(t, testFSM.AvailableEvents(), []eventVal{deliverEvent, cancelEvent})
+
+	// Regions add their own events
+	forkFSM, _ := NewFsm[string, string, NA, NA](approvalFac, "draft")
+	_, _ = forkFSM.Trigger("submit")
+	assert.DeepEqual(t, forkFSM.AvailableEvents(), []string{"reject", "legalOK", "financeOK"})
+
+	// Pop only when the stack is not empty
+	dialog, _ := NewFsm[string, string, NA, NA](dialogFac, "dialog")
+	assert.DeepEqual(t, dialog.AvailableEvents(), []string{"help"})
+	_, _ = dialog.Trigger("help")
+	assert.DeepEqual(t, dialog.AvailableEvents(), []string{"back"})
+}
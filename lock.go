@@ -0,0 +1,45 @@
+package fsm
+
+import (
+	"time"
+)
+
+// chanLock A mutex that supports non-blocking and timed acquisition
+type chanLock chan struct{}
+
+func newChanLock() chanLock {
+	return make(chanLock, 1)
+}
+
+func (l chanLock) Lock() {
+	l <- struct{}{}
+}
+
+func (l chanLock) Unlock() {
+	<-l
+}
+
+// TryLock acquire the lock only if it is free
+func (l chanLock) TryLock() bool {
+	select {
+	case l <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// LockTimeout acquire the lock, give up after d
+func (l chanLock) LockTimeout(d time.Duration) bool {
+	if d <= 0 {
+		return l.TryLock()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case l <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,194 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+)
+
+// MailboxPolicy Back-pressure behavior when the mailbox is full
+type MailboxPolicy int
+
+const (
+	MailboxBlock MailboxPolicy = iota // Send blocks until there is room in the mailbox
+	MailboxDrop                       // Send discards the event. The result channel receives MailboxFullErr
+	MailboxError                      // Send returns MailboxFullErr immediately
+)
+
+const defaultMailboxSize = 64
+
+type (
+	// MailboxConfig Config of the mailbox goroutine started by FSM.Start
+	MailboxConfig struct {
+		Size   int           // Capacity of the mailbox. Use default size if <= 0
+		Policy MailboxPolicy // What Send does when the mailbox is full
+	}
+
+	// TriggerResult Result of an asynchronous Trigger
+	TriggerResult[T, S comparable, U, V any] struct {
+		Event *Event[T, S, U, V]
+		Err   error
+	}
+
+	// mail One event waiting in the mailbox
+	mail[T, S comparable, U, V any] struct {
+		eventVal S
+		args     []interface{}
+		resp     chan *TriggerResult[T, S, U, V]
+	}
+
+	// mailbox Bounded event queue consumed by a single goroutine
+	mailbox[T, S comparable, U, V any] struct {
+		ch      chan *mail[T, S, U, V]
+		quit    chan struct{} // Closed when stop is requested. Unblocks waiting senders
+		done    chan struct{} // Closed when the goroutine exits
+		policy  MailboxPolicy
+		stopped bool
+		once    sync.Once
+		mutex   sync.RWMutex // Senders hold read lock so that ch is never closed under them
+	}
+)
+
+// Start spawn a goroutine that consumes events sent by Send in order
+// The goroutine exits when ctx is done or Stop is called
+func (f *FSM[T, S, U, V]) Start(ctx context.Context) error {
+	f.mbMutex.Lock()
+	defer f.mbMutex.Unlock()
+
+	if f.mailbox != nil {
+		return &MailboxStartedErr{}
+	}
+
+	cfg := f.mailboxConfig
+	if cfg == nil {
+		cfg = &MailboxConfig{}
+	}
+	size := cfg.Size
+	if size <= 0 {
+		size = defaultMailboxSize
+	}
+	mb := &mailbox[T, S, U, V]{
+		ch:     make(chan *mail[T, S, U, V], size),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+		policy: cfg.Policy,
+	}
+	f.mailbox = mb
+	go f.consume(ctx, mb)
+	return nil
+}
+
+// Stop stop accepting new events, wait until all queued events are processed
+func (f *FSM[T, S, U, V]) Stop() error {
+	f.mbMutex.Lock()
+	mb := f.mailbox
+	f.mailbox = nil
+	f.mbMutex.Unlock()
+
+	if mb == nil {
+		return &MailboxNotStartedErr{}
+	}
+	mb.close()
+	<-mb.done
+	return nil
+}
+
+// Send put an event into the mailbox
+// The returned channel receives exactly one TriggerResult once the event is processed
+func (f *FSM[T, S, U, V]) Send(eventVal S, args ...interface{}) (<-chan *TriggerResult[T, S, U, V], error) {
+	f.mbMutex.Lock()
+	mb := f.mailbox
+	f.mbMutex.Unlock()
+
+	if mb == nil {
+		return nil, &MailboxNotStartedErr{}
+	}
+
+	m := &mail[T, S, U, V]{
+		eventVal: eventVal,
+		args:     args,
+		resp:     make(chan *TriggerResult[T, S, U, V], 1),
+	}
+	if err := mb.put(m); err != nil {
+		if _, ok := err.(*MailboxFullErr); ok && mb.policy == MailboxDrop {
+			m.resp <- &TriggerResult[T, S, U, V]{Err: err}
+			return m.resp, nil
+		}
+		return nil, err
+	}
+	return m.resp, nil
+}
+
+// consume Process mails one by one
+func (f *FSM[T, S, U, V]) consume(ctx context.Context, mb *mailbox[T, S, U, V]) {
+	defer close(mb.done)
+	for {
+		select {
+		case m, ok := <-mb.ch:
+			if !ok {
+				return
+			}
+			// Both cases may be ready, ctx wins
+			if ctx.Err() != nil {
+				m.resp <- &TriggerResult[T, S, U, V]{Err: ctx.Err()}
+				f.abandon(ctx, mb)
+				return
+			}
+			e, err := f.Trigger(m.eventVal, m.args...)
+			m.resp <- &TriggerResult[T, S, U, V]{Event: e, Err: err}
+		case <-ctx.Done():
+			f.abandon(ctx, mb)
+			return
+		}
+	}
+}
+
+// abandon Reject everything left in mb without processing, once ctx is done
+func (f *FSM[T, S, U, V]) abandon(ctx context.Context, mb *mailbox[T, S, U, V]) {
+	go mb.close()
+	for m := range mb.ch {
+		m.resp <- &TriggerResult[T, S, U, V]{Err: ctx.Err()}
+	}
+	// Allow Start again, unless Stop or a new Start already replaced it
+	f.mbMutex.Lock()
+	if f.mailbox == mb {
+		f.mailbox = nil
+	}
+	f.mbMutex.Unlock()
+}
+
+// put Enqueue a mail according to policy
+func (mb *mailbox[T, S, U, V]) put(m *mail[T, S, U, V]) error {
+	mb.mutex.RLock()
+	defer mb.mutex.RUnlock()
+
+	if mb.stopped {
+		return &MailboxStoppedErr{}
+	}
+
+	if mb.policy == MailboxBlock {
+		select {
+		case mb.ch <- m:
+			return nil
+		case <-mb.quit:
+			return &MailboxStoppedErr{}
+		}
+	}
+
+	select {
+	case mb.ch <- m:
+		return nil
+	default:
+		return &MailboxFullErr{}
+	}
+}
+
+// close Stop accepting mails. Safe to call more than once
+func (mb *mailbox[T, S, U, V]) close() {
+	mb.once.Do(func() {
+		close(mb.quit)
+		mb.mutex.Lock()
+		mb.stopped = true
+		close(mb.ch)
+		mb.mutex.Unlock()
+	})
+}
@@ -0,0 +1,221 @@
+package fsm
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestFSM_Send(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+	assert.NilError(t, testFSM.Start(context.Background()))
+
+	tests := generateNonErrorTests()
+	results := make([]<-chan *TriggerResult[nodeState, eventVal, edgeVal, nodeVal], 0, len(tests))
+	for _, tt := range tests {
+		ch, err := testFSM.Send(tt.eventName)
+		assert.NilError(t, err)
+		results = append(results, ch)
+	}
+
+	// Processed in order
+	for i, ch := range results {
+		r := <-ch
+		assert.NilError(t, r.Err)
+		assert.Equal(t, r.Event.FromState(), tests[i].wantW.fromState)
+		assert.Equal(t, r.Event.ToState(), tests[i].wantW.toState)
+	}
+
+	assert.NilError(t, testFSM.Stop())
+	_, err := testFSM.Send(payEvent)
+	_, ok := err.(*MailboxNotStartedErr)
+	assert.Assert(t, ok)
+}
+
+func TestFSM_Send_Policy(t *testing.T) {
+
+	g, _ := descFac.NewG()
+	testFSM := NewFsmByG[nodeState, eventVal, edgeVal, nodeVal](g, initial)
+	testFSM.SetMailboxConfig(&MailboxConfig{Size: 1, Policy: MailboxError})
+
+	// Hold the consumer inside Trigger
+	block := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	testFSM.SetCallbacks(&Callbacks[nodeState, eventVal, edgeVal, nodeVal]{
+		onEntry: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+			entered <- struct{}{}
+			<-block
+			return nil
+		},
+	})
+	assert.NilError(t, testFSM.Start(context.Background()))
+
+	first, err := testFSM.Send(payEvent)
+	assert.NilError(t, err)
+	<-entered
+	second, err := testFSM.Send(deliverEvent)
+	assert.NilError(t, err)
+	_, err = testFSM.Send(receiveEvent)
+	_, ok := err.(*MailboxFullErr)
+	assert.Assert(t, ok)
+
+	// Stop drains queued events
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NilError(t, testFSM.Stop())
+	}()
+	close(block)
+	wg.Wait()
+	assert.NilError(t, (<-first).Err)
+	assert.NilError(t, (<-second).Err)
+	assert.Equal(t, testFSM.CurrState(), nodeState(delivering))
+}
+
+// heldFSM Consumer of its mailbox waits in onEntry of every event until block receives or is closed
+func heldFSM(cfg *MailboxConfig) (f *FSM[nodeState, eventVal, edgeVal, nodeVal], block chan struct{}, entered chan struct{}) {
+	g, _ := descFac.NewG()
+	f = NewFsmByG[nodeState, eventVal, edgeVal, nodeVal](g, initial)
+	f.SetMailboxConfig(cfg)
+	block = make(chan struct{})
+	entered = make(chan struct{}, 4)
+	f.SetCallbacks(&Callbacks[nodeState, eventVal, edgeVal, nodeVal]{
+		onEntry: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+			entered <- struct{}{}
+			<-block
+			return nil
+		},
+	})
+	return f, block, entered
+}
+
+// waitBlockedSender Wait until a Send holds the mailbox, which only happens while it waits for room
+func waitBlockedSender(mb *mailbox[nodeState, eventVal, edgeVal, nodeVal]) {
+	for mb.mutex.TryLock() {
+		mb.mutex.Unlock()
+		runtime.Gosched()
+	}
+}
+
+func TestFSM_Send_Block(t *testing.T) {
+
+	testFSM, block, entered := heldFSM(&MailboxConfig{Size: 1, Policy: MailboxBlock})
+	assert.NilError(t, testFSM.Start(context.Background()))
+	mb := testFSM.mailbox
+
+	first, err := testFSM.Send(payEvent)
+	assert.NilError(t, err)
+	<-entered
+	second, err := testFSM.Send(deliverEvent)
+	assert.NilError(t, err)
+
+	// Full: Send waits for room
+	type sent struct {
+		ch  <-chan *TriggerResult[nodeState, eventVal, edgeVal, nodeVal]
+		err error
+	}
+	third := make(chan sent, 1)
+	go func() {
+		ch, err := testFSM.Send(receiveEvent)
+		third <- sent{ch, err}
+	}()
+	waitBlockedSender(mb)
+	assert.Equal(t, len(third), 0)
+	block <- struct{}{}
+	<-entered
+	r := <-third
+	assert.NilError(t, r.err)
+
+	// Stop wakes up waiting senders
+	fourth := make(chan error, 1)
+	go func() {
+		_, err := testFSM.Send(payEvent)
+		fourth <- err
+	}()
+	waitBlockedSender(mb)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- testFSM.Stop()
+	}()
+	assert.ErrorType(t, <-fourth, &MailboxStoppedErr{})
+	close(block)
+	assert.NilError(t, <-stopped)
+	assert.NilError(t, (<-first).Err)
+	assert.NilError(t, (<-second).Err)
+	assert.NilError(t, (<-r.ch).Err)
+	assert.Equal(t, testFSM.CurrState(), nodeState(done))
+}
+
+func TestFSM_Send_Drop(t *testing.T) {
+
+	testFSM, block, entered := heldFSM(&MailboxConfig{Size: 1, Policy: MailboxDrop})
+	assert.NilError(t, testFSM.Start(context.Background()))
+
+	first, err := testFSM.Send(payEvent)
+	assert.NilError(t, err)
+	<-entered
+	second, err := testFSM.Send(deliverEvent)
+	assert.NilError(t, err)
+
+	// Dropped events are answered at once
+	third, err := testFSM.Send(receiveEvent)
+	assert.NilError(t, err)
+	assert.ErrorType(t, (<-third).Err, &MailboxFullErr{})
+
+	close(block)
+	assert.NilError(t, testFSM.Stop())
+	assert.NilError(t, (<-first).Err)
+	assert.NilError(t, (<-second).Err)
+	assert.Equal(t, testFSM.CurrState(), nodeState(delivering))
+}
+
+func TestFSM_Send_Cancel(t *testing.T) {
+
+	testFSM, block, entered := heldFSM(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NilError(t, testFSM.Start(ctx))
+	mb := testFSM.mailbox
+
+	first, err := testFSM.Send(payEvent)
+	assert.NilError(t, err)
+	<-entered
+	second, err := testFSM.Send(deliverEvent)
+	assert.NilError(t, err)
+	third, err := testFSM.Send(receiveEvent)
+	assert.NilError(t, err)
+
+	// The running event completes, queued ones are rejected
+	cancel()
+	close(block)
+	assert.NilError(t, (<-first).Err)
+	assert.Equal(t, (<-second).Err, context.Canceled)
+	assert.Equal(t, (<-third).Err, context.Canceled)
+	assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+
+	// Mailbox is gone with ctx
+	<-mb.done
+	assert.ErrorType(t, testFSM.Stop(), &MailboxNotStartedErr{})
+}
+
+func TestFSM_Start_AfterCancel(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NilError(t, testFSM.Start(ctx))
+	testFSM.mbMutex.Lock()
+	mb := testFSM.mailbox
+	testFSM.mbMutex.Unlock()
+	cancel()
+	<-mb.done
+
+	// The consumer exited with ctx, so the mailbox can start again
+	assert.NilError(t, testFSM.Start(context.Background()))
+	ch, err := testFSM.Send(payEvent)
+	assert.NilError(t, err)
+	assert.NilError(t, (<-ch).Err)
+	assert.NilError(t, testFSM.Stop())
+}
@@ -0,0 +1,32 @@
+package fsm
+
+type (
+	// Handler Run one transition of eventVal with args
+	Handler[T, S comparable, U, V any] func(eventVal S, args []interface{}) (*Event[T, S, U, V], error)
+
+	// Middleware Wrap a Handler. It may inspect or replace args, skip next to short-circuit, or call it again to retry
+	Middleware[T, S comparable, U, V any] func(next Handler[T, S, U, V]) Handler[T, S, U, V]
+)
+
+// Use append middlewares. The first one used is the outermost
+// Middlewares run while FSM holds its lock, for every Trigger, Send, timer and re-dispatched deferred event
+func (f *FSM[T, S, U, V]) Use(middlewares ...Middleware[T, S, U, V]) {
+	if !f.noSync {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+	}
+	f.middlewares = append(f.middlewares, middlewares...)
+	var h Handler[T, S, U, V] = f.transit
+	for i := len(f.middlewares) - 1; i >= 0; i -= 1 {
+		h = f.middlewares[i](h)
+	}
+	f.handler = h
+}
+
+// handle Run one transition through middlewares. Caller must hold the lock
+func (f *FSM[T, S, U, V]) handle(eventVal S, args []interface{}) (*Event[T, S, U, V], error) {
+	if f.handler == nil {
+		return f.transit(eventVal, args)
+	}
+	return f.handler(eventVal, args)
+}
@@ -0,0 +1,85 @@
+package fsm
+
+import (
+	"errors"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+type testHandler = Handler[nodeState, eventVal, edgeVal, nodeVal]
+
+func TestFSM_Use(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+
+	var log []string
+	denied := errors.New("denied")
+	testFSM.Use(
+		// Observe
+		func(next testHandler) testHandler {
+			return func(ev eventVal, args []interface{}) (*Event[nodeState, eventVal, edgeVal, nodeVal], error) {
+				log = append(log, "in "+string(ev))
+				e, err := next(ev, args)
+				log = append(log, "out "+string(ev))
+				return e, err
+			}
+		},
+		// Short-circuit
+		func(next testHandler) testHandler {
+			return func(ev eventVal, args []interface{}) (*Event[nodeState, eventVal, edgeVal, nodeVal], error) {
+				if ev == cancelEvent {
+					return nil, denied
+				}
+				return next(ev, append(args, "traced"))
+			}
+		},
+	)
+
+	cb := &Callbacks[nodeState, eventVal, edgeVal, nodeVal]{}
+	cb.SetOnEntry(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		log = append(log, "entry")
+		return nil
+	})
+	testFSM.SetCallbacks(cb)
+
+	e, err := testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, e.Args(), []interface{}{"traced"})
+
+	_, err = testFSM.Trigger(cancelEvent)
+	assert.Equal(t, err, denied)
+	assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+
+	assert.DeepEqual(t, log, []string{"in payEvent", "entry", "out payEvent", "in cancelEvent", "out cancelEvent"})
+}
+
+func TestFSM_Use_Retry(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+
+	fails := 2
+	cb := &Callbacks[nodeState, eventVal, edgeVal, nodeVal]{}
+	cb.SetBeforeStateChange(func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		if fails > 0 {
+			fails -= 1
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	testFSM.SetCallbacks(cb)
+
+	testFSM.Use(func(next testHandler) testHandler {
+		return func(ev eventVal, args []interface{}) (e *Event[nodeState, eventVal, edgeVal, nodeVal], err error) {
+			for i := 0; i < 3; i += 1 {
+				if e, err = next(ev, args); err == nil {
+					return
+				}
+			}
+			return
+		}
+	})
+
+	_, err := testFSM.Trigger(payEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+}
@@ -0,0 +1,103 @@
+package fsm
+
+// Regions State of each orthogonal region. Nil if current state is not a fork
+func (f *FSM[T, S, U, V]) Regions() []T {
+	return append([]T(nil), f.load().Regions...)
+}
+
+// regionsOf Initial region states when entering state
+func (f *FSM[T, S, U, V]) regionsOf(state T) []T {
+	v := f.g.VertexByState(state)
+	if v == nil {
+		return nil
+	}
+	return f.g.Regions(v)
+}
+
+// regionEdge Find the first region that accepts eventVal
+// The state stack belongs to the FSM itself, so stack transitions are ignored in regions
+func (f *FSM[T, S, U, V]) regionEdge(eventVal S) (int, *Edge[T, S, U, V]) {
+	for i, r := range f.load().Regions {
+		if edge, err := f.g.NextEdge(r, eventVal); err == nil && edge.stackOp == StackNone {
+			return i, edge
+		}
+	}
+	return -1, nil
+}
+
+// transitRegion Move one region of current fork. Fire the join if every region reaches its source state
+// Caller must hold the lock
+func (f *FSM[T, S, U, V]) transitRegion(e *Event[T, S, U, V], region int, edge *Edge[T, S, U, V]) (*Event[T, S, U, V], error) {
+	snap := f.load()
+	e.eventE = edge
+	e.region = region
+
+	if edge.internal {
+		if err := f.runHooks(phaseGuard, e); err != nil {
+			return e, err
+		}
+		if err := f.runHooks(phaseOnInternal, e); err != nil {
+			return e, err
+		}
+		f.publish(e)
+		return e, nil
+	}
+
+	if err := f.resolve(e, VertexJunction); err != nil {
+		return e, err
+	}
+
+	// Before state change
+	if err := f.runHooks(phaseGuard, e); err != nil {
+		return e, err
+	}
+	if err := f.runHooks(phaseBeforeStateChange, e); err != nil {
+		return e, err
+	}
+
+	if err := f.resolve(e, VertexChoice, VertexJunction); err != nil {
+		return e, err
+	}
+
+	regions := append([]T(nil), snap.Regions...)
+	regions[region] = e.ToV().stateVal
+	entered := regions[region]
+
+	joined := f.g.Join(regions, region)
+	if joined != nil {
+		// Leave the fork through the join, then resolve what follows
+		e.branches = append(e.branches, joined...)
+		if err := f.resolve(e, VertexChoice, VertexJunction); err != nil {
+			return e, err
+		}
+		entered = e.ToV().stateVal
+		f.setState(snap.Curr, entered, edge, f.regionsOf(entered), snap.Stack)
+	} else {
+		f.setState(snap.Prev, snap.Curr, edge, regions, snap.Stack)
+	}
+
+	// After state change
+	err := f.runHooks(phaseAfterStateChange, e)
+
+	if err == nil {
+		err = f.checkInvariants(e, entered, snap)
+	}
+
+	// Timeouts are armed last, see transit
+	if !f.rolledBack(err) {
+		if joined != nil {
+			f.armStateTimers()
+		} else {
+			f.armRegionTimers(snap.Regions[region], entered)
+		}
+	}
+	f.wakeWaiters()
+	if err != nil {
+		return e, err
+	}
+
+	// Notify subscribers
+	f.publish(e)
+
+	return e, nil
+}
@@ -0,0 +1,182 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+// approvalFac Legal and finance review a document in parallel
+var approvalFac = &DefConfig[string, string, NA, NA]{
+	DescList: []*DescCell[string, string, NA, NA]{
+		{EventVal: "submit", FromState: []string{"draft"}, ToState: "reviewing"},
+		{EventVal: "reject", FromState: []string{"reviewing"}, ToState: "draft"},
+		{EventVal: "legalOK", FromState: []string{"legalReview"}, ToState: "legalDone"},
+		{EventVal: "financeOK", FromState: []string{"financeReview"}, ToState: "financeDone"},
+	},
+	PseudoMap: map[string]*PseudoDesc[string, string, NA, NA]{
+		"reviewing": {
+			Kind: VertexFork,
+			Branches: []*BranchDesc[string, string, NA, NA]{
+				{ToState: "legalReview"},
+				{ToState: "financeReview"},
+			},
+		},
+		"reviewed": {
+			Kind:     VertexJoin,
+			Sources:  []string{"legalDone", "financeDone"},
+			Branches: []*BranchDesc[string, string, NA, NA]{{ToState: "approved"}},
+		},
+	},
+}
+
+func TestFSM_ForkJoin(t *testing.T) {
+
+	testFSM, err := NewFsm[string, string, NA, NA](approvalFac, "draft")
+	assert.NilError(t, err)
+
+	_, err = testFSM.Trigger("submit")
+	assert.NilError(t, err)
+	assert.Equal(t, testFSM.CurrState(), "reviewing")
+	assert.DeepEqual(t, testFSM.Regions(), []string{"legalReview", "financeReview"})
+
+	e, err := testFSM.Trigger("legalOK")
+	assert.NilError(t, err)
+	assert.Equal(t, e.Region(), 0)
+	assert.Equal(t, e.ToState(), "legalDone")
+	assert.Equal(t, testFSM.CurrState(), "reviewing")
+	assert.DeepEqual(t, testFSM.Regions(), []string{"legalDone", "financeReview"})
+
+	// Not ready to join twice
+	_, err = testFSM.Trigger("legalOK")
+	assert.Assert(t, err != nil)
+
+	e, err = testFSM.Trigger("financeOK")
+	assert.NilError(t, err)
+	assert.Equal(t, e.Region(), 1)
+	assert.Equal(t, e.ToState(), "approved")
+	assert.Equal(t, testFSM.CurrState(), "approved")
+	assert.Equal(t, testFSM.PrevState(), "reviewing")
+	assert.Equal(t, len(testFSM.Regions()), 0)
+}
+
+func TestFSM_ForkExit(t *testing.T) {
+
+	testFSM, _ := NewFsm[string, string, NA, NA](approvalFac, "draft")
+	for _, ev := range []string{"submit", "legalOK", "reject"} {
+		_, err := testFSM.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, testFSM.CurrState(), "draft")
+	assert.Equal(t, len(testFSM.Regions()), 0)
+
+	// Re-entering starts every region again
+	_, _ = testFSM.Trigger("submit")
+	assert.DeepEqual(t, testFSM.Regions(), []string{"legalReview", "financeReview"})
+}
+
+func TestGraph_ForkJoin_Path(t *testing.T) {
+
+	g, err := approvalFac.NewG()
+	assert.NilError(t, err)
+	assert.Assert(t, g.HasPathTo("draft", "approved"))
+	assert.Assert(t, g.HasPathTo("reviewing", "financeDone"))
+	assert.Assert(t, !g.HasPathTo("approved", "draft"))
+}
+
+// splitFac Join waits for one region only, the other one ends in a state without edges
+func splitFac(regionStarts ...string) *DefConfig[string, string, NA, NA] {
+	fac := &DefConfig[string, string, NA, NA]{
+		DescList: []*DescCell[string, string, NA, NA]{
+			{EventVal: "enter", FromState: []string{"start"}, ToState: "split"},
+			{EventVal: "a", FromState: []string{"A1"}, ToState: "A2"},
+			{EventVal: "go", FromState: []string{"C1"}, ToState: "C2"},
+		},
+		PseudoMap: map[string]*PseudoDesc[string, string, NA, NA]{
+			"split": {Kind: VertexFork},
+			"joined": {
+				Kind:     VertexJoin,
+				Sources:  []string{"A2"},
+				Branches: []*BranchDesc[string, string, NA, NA]{{ToState: "done"}},
+			},
+		},
+	}
+	for _, s := range regionStarts {
+		fac.PseudoMap["split"].Branches = append(fac.PseudoMap["split"].Branches, &BranchDesc[string, string, NA, NA]{ToState: s})
+	}
+	return fac
+}
+
+func TestFSM_Join_NotSource(t *testing.T) {
+
+	// A region starting in a join source is rejected
+	_, err := splitFac("A2", "C1").NewG()
+	assert.ErrorType(t, err, &InvalidPseudoStateErr[string]{})
+
+	g, err := splitFac("A1", "C1").NewG()
+	assert.NilError(t, err)
+
+	// Moving a region that is not a source never fires the join, even into a state without edges
+	assert.Assert(t, g.Join([]string{"A2", "C2"}, 1) == nil)
+
+	testFSM := NewFsmByG(g, "start")
+	for _, ev := range []string{"enter", "go"} {
+		_, err = testFSM.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, testFSM.CurrState(), "split")
+	assert.DeepEqual(t, testFSM.Regions(), []string{"A1", "C2"})
+
+	_, err = testFSM.Trigger("a")
+	assert.NilError(t, err)
+	assert.Equal(t, testFSM.CurrState(), "done")
+}
+
+func TestFSM_Region_Timeout(t *testing.T) {
+
+	fac := &DefConfig[string, string, NA, NA]{
+		DescList:  append([]*DescCell[string, string, NA, NA]{{EventVal: "expire", FromState: []string{"legalReview"}, ToState: "legalDone", After: time.Minute}}, approvalFac.DescList...),
+		PseudoMap: approvalFac.PseudoMap,
+	}
+	g, err := fac.NewG()
+	assert.NilError(t, err)
+	clock := NewFakeClock(time.Unix(0, 0))
+	testFSM := NewFsmByGWithClock(g, "draft", clock)
+
+	// Armed when the region enters its state
+	_, err = testFSM.Trigger("submit")
+	assert.NilError(t, err)
+	assert.Equal(t, clock.Pending(), 1)
+	clock.Advance(2 * time.Minute)
+	assert.DeepEqual(t, testFSM.Regions(), []string{"legalDone", "financeReview"})
+	assert.Equal(t, clock.Pending(), 0)
+
+	// Canceled when the region leaves it
+	for _, ev := range []string{"reject", "submit", "legalOK"} {
+		_, err = testFSM.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, clock.Pending(), 0)
+
+	// Canceled when the fork is left
+	for _, ev := range []string{"reject", "submit", "reject"} {
+		_, err = testFSM.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, clock.Pending(), 0)
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, testFSM.CurrState(), "draft")
+
+	// Restored with the original deadline
+	store := NewMemTimerStore[string, string]()
+	testFSM.SetTimerStore(store)
+	_, err = testFSM.Trigger("submit")
+	assert.NilError(t, err)
+	restarted := NewFakeClock(clock.Now().Add(30 * time.Second))
+	after := NewFsmByGWithClock(g, "reviewing", restarted)
+	after.SetTimerStore(store)
+	assert.NilError(t, after.RestoreTimers(CatchUpFire))
+	assert.Equal(t, restarted.Pending(), 1)
+	restarted.Advance(31 * time.Second)
+	assert.DeepEqual(t, after.Regions(), []string{"legalDone", "financeReview"})
+}
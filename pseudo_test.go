@@ -0,0 +1,128 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+const (
+	payChoice       nodeState = 100 // Express orders skip paid
+	receiveJunction nodeState = 101 // Damaged goods are canceled
+)
+
+func newPseudoFac(left *bool) *DefConfig[nodeState, eventVal, edgeVal, nodeVal] {
+	isArg := func(arg string) func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+		return func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+			return len(e.Args()) > 0 && e.Args()[0] == arg
+		}
+	}
+	return &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+		DescList: []*DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+			{EventVal: payEvent, FromState: []nodeState{initial}, ToState: payChoice},
+			{EventVal: deliverEvent, FromState: []nodeState{paid}, ToState: delivering},
+			{EventVal: receiveEvent, FromState: []nodeState{delivering}, ToState: receiveJunction},
+		},
+		PseudoMap: map[nodeState]*PseudoDesc[nodeState, eventVal, edgeVal, nodeVal]{
+			payChoice: {
+				Kind: VertexChoice,
+				Branches: []*BranchDesc[nodeState, eventVal, edgeVal, nodeVal]{
+					{
+						Guard: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+							return *left && isArg("express")(e)
+						},
+						ToState: delivering,
+					},
+					{ToState: paid},
+				},
+			},
+			receiveJunction: {
+				Kind: VertexJunction,
+				Branches: []*BranchDesc[nodeState, eventVal, edgeVal, nodeVal]{
+					{
+						Guard: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) bool {
+							return !*left && isArg("damaged")(e)
+						},
+						ToState: canceled,
+					},
+					{Guard: isArg("ok"), ToState: done},
+				},
+			},
+		},
+	}
+}
+
+func TestFSM_Pseudo(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		events    []eventVal
+		args      []interface{} // Args of the last event
+		wantState nodeState
+		wantErr   bool
+	}{
+		{name: "choice else", events: []eventVal{payEvent}, wantState: paid},
+		{name: "choice express", events: []eventVal{payEvent}, args: []interface{}{"express"}, wantState: delivering},
+		{name: "junction ok", events: []eventVal{payEvent, deliverEvent, receiveEvent}, args: []interface{}{"ok"}, wantState: done},
+		{name: "junction damaged", events: []eventVal{payEvent, deliverEvent, receiveEvent}, args: []interface{}{"damaged"}, wantState: canceled},
+		{name: "junction no branch", events: []eventVal{payEvent, deliverEvent, receiveEvent}, wantState: delivering, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Choice guards run after beforeStateChange, junction guards before
+			left := false
+			testFSM, err := NewFsm[nodeState, eventVal, edgeVal, nodeVal](newPseudoFac(&left), initial)
+			assert.NilError(t, err)
+			testFSM.SetCallbacks(&Callbacks[nodeState, eventVal, edgeVal, nodeVal]{
+				onEntry: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+					left = false
+					return nil
+				},
+				beforeStateChange: func(e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+					left = true
+					return nil
+				},
+			})
+
+			var e *Event[nodeState, eventVal, edgeVal, nodeVal]
+			for i, ev := range tt.events {
+				var args []interface{}
+				if i == len(tt.events)-1 {
+					args = tt.args
+				}
+				e, err = testFSM.Trigger(ev, args...)
+			}
+			if tt.wantErr {
+				_, ok := err.(*NoBranchErr[nodeState])
+				assert.Assert(t, ok)
+			} else {
+				assert.NilError(t, err)
+				assert.Equal(t, e.ToState(), tt.wantState)
+				assert.Equal(t, len(e.Branches()), 1)
+			}
+			assert.Equal(t, testFSM.CurrState(), tt.wantState)
+		})
+	}
+}
+
+func TestGraph_Pseudo_Path(t *testing.T) {
+
+	left := false
+	g, err := newPseudoFac(&left).NewG()
+	assert.NilError(t, err)
+
+	// Every branch is a possible path
+	assert.Assert(t, g.HasPathTo(initial, canceled))
+	assert.Assert(t, g.HasPathTo(initial, done))
+	assert.Assert(t, !g.HasPathTo(done, initial))
+	assert.Equal(t, len(g.AllPathTo(initial, delivering)), 2)
+
+	t.Run("pseudo-state handles event", func(t *testing.T) {
+		fac := newPseudoFac(&left)
+		fac.DescList = append(fac.DescList, &DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+			EventVal: cancelEvent, FromState: []nodeState{payChoice}, ToState: canceled,
+		})
+		_, err := fac.NewG()
+		_, ok := err.(*InvalidPseudoStateErr[nodeState])
+		assert.Assert(t, ok)
+	})
+}
@@ -0,0 +1,28 @@
+package fsm
+
+// Stack States saved by push transitions, bottom first
+// Callbacks before the state change see the stack before the transition, callbacks after it see the new one
+func (f *FSM[T, S, U, V]) Stack() []T {
+	return append([]T(nil), f.load().Stack...)
+}
+
+// stackOf Apply the stack operation of edge. Pop picks the edge back to the state on top
+// The stack in the current snapshot is never modified
+func (f *FSM[T, S, U, V]) stackOf(currState T, edge *Edge[T, S, U, V]) (*Edge[T, S, U, V], []T, error) {
+	stack := f.load().Stack
+	switch edge.stackOp {
+	case StackPush:
+		return edge, append(append(make([]T, 0, len(stack)+1), stack...), currState), nil
+	case StackPop:
+		if len(stack) == 0 {
+			return edge, nil, &EmptyStackErr[T, S]{State: currState, Event: edge.eventVal}
+		}
+		top := len(stack) - 1
+		popEdge, err := f.g.PopEdge(currState, edge.eventVal, stack[top])
+		if err != nil {
+			return edge, nil, err
+		}
+		return popEdge, stack[:top:top], nil
+	}
+	return edge, stack, nil
+}
@@ -0,0 +1,104 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+// dialogFac Dialogs can be opened from several pages and return to the page that opened them
+var dialogFac = &DefConfig[string, string, NA, NA]{
+	DescList: []*DescCell[string, string, NA, NA]{
+		{EventVal: "next", FromState: []string{"home"}, ToState: "settings"},
+		{EventVal: "confirm", FromState: []string{"home", "settings"}, ToState: "dialog", Stack: StackPush},
+		{EventVal: "help", FromState: []string{"dialog"}, ToState: "helpPage", Stack: StackPush},
+		{EventVal: "close", FromState: []string{"dialog"}, Stack: StackPop},
+		{EventVal: "back", FromAny: true, Except: []string{"home"}, Stack: StackPop},
+	},
+}
+
+func TestFSM_Pushdown(t *testing.T) {
+
+	testFSM, err := NewFsm[string, string, NA, NA](dialogFac, "home")
+	assert.NilError(t, err)
+
+	var stacks [][]string
+	cb := &Callbacks[string, string, NA, NA]{}
+	cb.SetAfterStateChange(func(e *Event[string, string, NA, NA]) error {
+		stacks = append(stacks, e.FSM().Stack())
+		return nil
+	})
+	testFSM.SetCallbacks(cb)
+
+	// Nothing to return to
+	_, err = testFSM.Trigger("back")
+	_, ok := err.(*InvalidEventErr[string, string])
+	assert.Assert(t, ok)
+	testFSM.ForceSetCurrState("settings")
+	assert.Assert(t, !testFSM.CanTrigger("back"))
+	_, err = testFSM.Trigger("back")
+	_, ok = err.(*EmptyStackErr[string, string])
+	assert.Assert(t, ok)
+
+	for _, ev := range []string{"confirm", "help"} {
+		_, err = testFSM.Trigger(ev)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, testFSM.CurrState(), "helpPage")
+	assert.DeepEqual(t, testFSM.State().Stack, []string{"settings", "dialog"})
+
+	peek, ok := testFSM.PeekState("helpPage", "back")
+	assert.Assert(t, ok)
+	assert.Equal(t, peek, "dialog")
+
+	e, err := testFSM.Trigger("back")
+	assert.NilError(t, err)
+	assert.Equal(t, e.ToState(), "dialog")
+	assert.Equal(t, e.EventE().StackOp(), StackPop)
+
+	// Returns to the page that opened the dialog, not the first pushed state
+	e, err = testFSM.Trigger("close")
+	assert.NilError(t, err)
+	assert.Equal(t, e.ToState(), "settings")
+	assert.Equal(t, len(testFSM.Stack()), 0)
+
+	assert.DeepEqual(t, stacks, [][]string{
+		{"settings"},
+		{"settings", "dialog"},
+		{"settings"},
+		nil,
+	})
+}
+
+func TestGraph_Pushdown(t *testing.T) {
+
+	g, err := dialogFac.NewG()
+	assert.NilError(t, err)
+
+	// One pop edge per state that can be pushed
+	edges, err := g.NextEdges("dialog", "close")
+	assert.NilError(t, err)
+	assert.Equal(t, len(edges), 3)
+	assert.Assert(t, g.HasPathTo("helpPage", "home"))
+
+	_, err = (&DefConfig[string, string, NA, NA]{
+		DescList: []*DescCell[string, string, NA, NA]{
+			{EventVal: "close", FromState: []string{"dialog"}, ToState: "dialog", Internal: true, Stack: StackPop},
+		},
+	}).NewG()
+	_, ok := err.(*InvalidInternalTransitionErr[string, string])
+	assert.Assert(t, ok)
+
+	// Nothing to pop
+	for _, d := range []*DescCell[string, string, NA, NA]{
+		{EventVal: "close", FromState: []string{"dialog"}, Stack: StackPop},
+		{EventVal: "back", FromAny: true, Stack: StackPop},
+	} {
+		_, err = (&DefConfig[string, string, NA, NA]{
+			DescList: []*DescCell[string, string, NA, NA]{
+				{EventVal: "open", FromState: []string{"home"}, ToState: "dialog"},
+				d,
+			},
+		}).NewG()
+		assert.ErrorType(t, err, &NoPushErr[string]{})
+	}
+}
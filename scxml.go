@@ -0,0 +1,391 @@
+package fsm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const scxmlNS = "http://www.w3.org/2005/07/scxml"
+
+type (
+	// SCXMLConfig Build a Graph from a W3C SCXML document
+	// Nested states are flattened: only atomic states become vertices, transitions of a compound state apply to
+	// every descendant not handling the same event itself, and targeting a compound state enters its initial descendant.
+	// StatusValMap of the Graph holds the id of the parent compound state, empty at top level.
+	// Constructs without a counterpart, such as <parallel>, <history>, cond, eventless transitions and executable
+	// content, make NewG fail with UnsupportedSCXMLErr unless IgnoreUnsupported is set
+	SCXMLConfig struct {
+		IgnoreUnsupported bool // Drop unsupported constructs instead of failing
+
+		root        *scxmlNode
+		initial     string
+		final       []string
+		unsupported []string
+	}
+
+	// SCXMLExportOpts Options of Graph.WriteSCXML
+	SCXMLExportOpts[T comparable] struct {
+		Name              string // Optional. Name attribute of the document
+		Initial           T      // Initial state of the document
+		IgnoreUnsupported bool   // Leave out what SCXML can not express instead of failing
+		Final             []T    // Optional. States written as <final>. They must have no transition
+	}
+
+	// scxmlNode Any element of the document
+	scxmlNode struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr  `xml:",any,attr"`
+		Nodes   []scxmlNode `xml:",any"`
+	}
+
+	// scxmlState One state element with its position in the hierarchy
+	scxmlState struct {
+		node     *scxmlNode
+		id       string
+		parent   *scxmlState
+		children []*scxmlState
+	}
+)
+
+// Ensure interface implement
+var _ GraphConfig[string, string, string, string] = new(SCXMLConfig)
+
+// NewSCXMLConfig parse an SCXML document
+func NewSCXMLConfig(r io.Reader) (*SCXMLConfig, error) {
+	root := &scxmlNode{}
+	if err := xml.NewDecoder(r).Decode(root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Local != "scxml" {
+		return nil, &InvalidSCXMLErr{Reason: fmt.Sprintf("root element is <%s>", root.XMLName.Local)}
+	}
+	return &SCXMLConfig{root: root}, nil
+}
+
+// NewG New a Graph
+func (c *SCXMLConfig) NewG() (*Graph[string, string, string, string], error) {
+	c.final = nil
+	c.unsupported = nil
+
+	// Collect states
+	top := &scxmlState{node: c.root}
+	states := make(map[string]*scxmlState)
+	if err := c.collect(top, states); err != nil {
+		return nil, err
+	}
+	if len(top.children) == 0 {
+		return nil, &InvalidSCXMLErr{Reason: "no state"}
+	}
+
+	// Resolve initial state
+	initial, err := c.enter(top, states)
+	if err != nil {
+		return nil, err
+	}
+	c.initial = initial
+
+	// Flatten transitions into atomic states
+	fac := &DefConfig[string, string, string, string]{
+		StatusValMap: make(map[string]string),
+	}
+	var leaves []*scxmlState
+	var walk func(s *scxmlState)
+	walk = func(s *scxmlState) {
+		if len(s.children) == 0 && s != top {
+			leaves = append(leaves, s)
+		}
+		for _, child := range s.children {
+			walk(child)
+		}
+	}
+	walk(top)
+	for _, leaf := range leaves {
+		fac.StatusValMap[leaf.id] = leaf.parent.id
+		handled := make(map[string]struct{})
+		for s := leaf; s != top; s = s.parent {
+			for i := range s.node.Nodes {
+				n := &s.node.Nodes[i]
+				if n.XMLName.Local != "transition" {
+					continue
+				}
+				cells, err := c.transition(leaf, s, n, states, handled)
+				if err != nil {
+					return nil, err
+				}
+				fac.DescList = append(fac.DescList, cells...)
+			}
+		}
+	}
+
+	if len(c.unsupported) > 0 && !c.IgnoreUnsupported {
+		return nil, &UnsupportedSCXMLErr{Constructs: c.unsupported}
+	}
+
+	g, err := fac.NewG()
+	if err != nil {
+		return nil, err
+	}
+	return withVertices(g, fac, leaves), nil
+}
+
+// Initial Initial atomic state of the document. Available after NewG
+func (c *SCXMLConfig) Initial() string {
+	return c.initial
+}
+
+// Final States declared by <final> in document order. Available after NewG
+// Pass it to SCXMLExportOpts.Final to write them back as <final>
+func (c *SCXMLConfig) Final() []string {
+	return c.final
+}
+
+// Unsupported Constructs found by the last NewG
+func (c *SCXMLConfig) Unsupported() []string {
+	return c.unsupported
+}
+
+// collect Build the state tree under s, report unsupported elements
+func (c *SCXMLConfig) collect(s *scxmlState, states map[string]*scxmlState) error {
+	for i := range s.node.Nodes {
+		n := &s.node.Nodes[i]
+		switch n.XMLName.Local {
+		case "state", "final":
+			id := n.attr("id")
+			if id == "" {
+				return &InvalidSCXMLErr{Reason: fmt.Sprintf("<%s> without id in %s", n.XMLName.Local, s.name())}
+			}
+			if _, ok := states[id]; ok {
+				return &InvalidSCXMLErr{Reason: fmt.Sprintf("duplicate id %s", id)}
+			}
+			child := &scxmlState{node: n, id: id, parent: s}
+			states[id] = child
+			if n.XMLName.Local == "final" {
+				c.final = append(c.final, id)
+			}
+			s.children = append(s.children, child)
+			if err := c.collect(child, states); err != nil {
+				return err
+			}
+		case "transition":
+		case "initial":
+			if s.parent == nil {
+				c.report("<initial> in <scxml>")
+			}
+		default:
+			c.report(fmt.Sprintf("<%s> in %s", n.XMLName.Local, s.name()))
+		}
+	}
+	return nil
+}
+
+// enter Atomic state entered when targeting s
+func (c *SCXMLConfig) enter(s *scxmlState, states map[string]*scxmlState) (string, error) {
+	for len(s.children) > 0 {
+		target := s.node.attr("initial")
+		for i := range s.node.Nodes {
+			n := &s.node.Nodes[i]
+			if n.XMLName.Local != "initial" {
+				continue
+			}
+			for j := range n.Nodes {
+				if n.Nodes[j].XMLName.Local == "transition" {
+					target = n.Nodes[j].attr("target")
+				}
+			}
+		}
+		if target == "" {
+			s = s.children[0]
+			continue
+		}
+		if len(strings.Fields(target)) > 1 {
+			c.report(fmt.Sprintf("multiple initial targets in %s", s.name()))
+			target = strings.Fields(target)[0]
+		}
+		next, ok := states[target]
+		if !ok {
+			return "", &StateNotExistErr[string]{State: target}
+		}
+		s = next
+	}
+	return s.id, nil
+}
+
+// transition Describe transition n of s as seen from atomic state leaf
+// Events already handled by leaf or a nearer ancestor are skipped
+func (c *SCXMLConfig) transition(leaf, s *scxmlState, n *scxmlNode, states map[string]*scxmlState, handled map[string]struct{}) ([]*DescCell[string, string, string, string], error) {
+	events := strings.Fields(n.attr("event"))
+	if len(events) == 0 {
+		c.report(fmt.Sprintf("eventless transition in %s", s.name()))
+		return nil, nil
+	}
+	if n.attr("cond") != "" {
+		c.report(fmt.Sprintf("cond on transition %s in %s", n.attr("event"), s.name()))
+		return nil, nil
+	}
+	if len(n.Nodes) > 0 {
+		c.report(fmt.Sprintf("executable content in transition %s in %s", n.attr("event"), s.name()))
+	}
+
+	internal := false
+	toState := leaf.id
+	switch targets := strings.Fields(n.attr("target")); len(targets) {
+	case 0:
+		internal = true
+	case 1:
+		target, ok := states[targets[0]]
+		if !ok {
+			return nil, &StateNotExistErr[string]{State: targets[0]}
+		}
+		var err error
+		if toState, err = c.enter(target, states); err != nil {
+			return nil, err
+		}
+	default:
+		c.report(fmt.Sprintf("multiple targets of transition %s in %s", n.attr("event"), s.name()))
+		return nil, nil
+	}
+
+	var resp []*DescCell[string, string, string, string]
+	for _, ev := range events {
+		if ev == "*" || strings.HasSuffix(ev, ".*") {
+			c.report(fmt.Sprintf("wildcard event %s in %s", ev, s.name()))
+			continue
+		}
+		if _, ok := handled[ev]; ok {
+			continue
+		}
+		handled[ev] = struct{}{}
+		resp = append(resp, &DescCell[string, string, string, string]{
+			EventVal:  ev,
+			FromState: []string{leaf.id},
+			ToState:   toState,
+			Internal:  internal,
+		})
+	}
+	return resp, nil
+}
+
+// report Record an unsupported construct once. Transitions of compound states are visited once per descendant
+func (c *SCXMLConfig) report(construct string) {
+	for _, u := range c.unsupported {
+		if u == construct {
+			return
+		}
+	}
+	c.unsupported = append(c.unsupported, construct)
+}
+
+// withVertices Add atomic states never mentioned by a transition
+func withVertices(g *Graph[string, string, string, string], fac *DefConfig[string, string, string, string], leaves []*scxmlState) *Graph[string, string, string, string] {
+	for _, leaf := range leaves {
+		if g.VertexByState(leaf.id) != nil {
+			continue
+		}
+		v := fac.newV(leaf.id)
+		v.idx = len(g.itoV)
+		g.itoV = append(g.itoV, v)
+		g.stoV[v.stateVal] = v
+		g.adj = append(g.adj, nil)
+	}
+	return g
+}
+
+// name Description of s in reports
+func (s *scxmlState) name() string {
+	if s.parent == nil {
+		return "<scxml>"
+	}
+	return fmt.Sprintf("state %s", s.id)
+}
+
+// attr Value of attribute name. Empty if missing
+func (n *scxmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name && a.Name.Space == "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// WriteSCXML write g as an SCXML document. Only states listed in opts.Final are written as <final>
+// Pseudo-states, state timeouts, stack transitions and deferred events have no counterpart and make it fail
+// with UnsupportedSCXMLErr before anything is written, unless opts.IgnoreUnsupported is set
+func (g *Graph[T, S, U, V]) WriteSCXML(w io.Writer, opts *SCXMLExportOpts[T]) error {
+	if opts == nil {
+		opts = &SCXMLExportOpts[T]{}
+	}
+	if g.VertexByState(opts.Initial) == nil {
+		return &StateNotExistErr[T]{State: opts.Initial}
+	}
+
+	var unsupported []string
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<scxml xmlns=\"%s\" version=\"1.0\" initial=\"%s\"", scxmlNS, xmlEscape(fmt.Sprintf("%v", opts.Initial)))
+	if opts.Name != "" {
+		fmt.Fprintf(&b, " name=\"%s\"", xmlEscape(opts.Name))
+	}
+	b.WriteString(">\n")
+
+	for _, v := range g.itoV {
+		id := xmlEscape(fmt.Sprintf("%v", v.stateVal))
+		if v.kind != VertexState {
+			unsupported = append(unsupported, fmt.Sprintf("pseudo-state %v", v.stateVal))
+			continue
+		}
+		if len(g.deferred[v.stateVal]) > 0 {
+			unsupported = append(unsupported, fmt.Sprintf("deferred events in state %v", v.stateVal))
+		}
+		var transitions []string
+		if g.adj[v.idx] != nil {
+			for _, e := range g.adj[v.idx].eList {
+				switch {
+				case e.after > 0:
+					unsupported = append(unsupported, fmt.Sprintf("timeout of event %v in state %v", e.eventVal, v.stateVal))
+				case e.stackOp != StackNone:
+					unsupported = append(unsupported, fmt.Sprintf("stack transition of event %v in state %v", e.eventVal, v.stateVal))
+				case e.toV.kind != VertexState:
+					unsupported = append(unsupported, fmt.Sprintf("transition of event %v in state %v to pseudo-state", e.eventVal, v.stateVal))
+				case e.internal:
+					transitions = append(transitions, fmt.Sprintf("    <transition event=\"%s\"/>\n", xmlEscape(fmt.Sprintf("%v", e.eventVal))))
+				default:
+					transitions = append(transitions, fmt.Sprintf("    <transition event=\"%s\" target=\"%s\"/>\n",
+						xmlEscape(fmt.Sprintf("%v", e.eventVal)), xmlEscape(fmt.Sprintf("%v", e.toV.stateVal))))
+				}
+			}
+		}
+		if matchOne(opts.Final, v.stateVal) {
+			if g.adj[v.idx] != nil && len(g.adj[v.idx].eList) > 0 {
+				unsupported = append(unsupported, fmt.Sprintf("transitions of final state %v", v.stateVal))
+			}
+			fmt.Fprintf(&b, "  <final id=\"%s\"/>\n", id)
+			continue
+		}
+		if len(transitions) == 0 {
+			fmt.Fprintf(&b, "  <state id=\"%s\"/>\n", id)
+			continue
+		}
+		fmt.Fprintf(&b, "  <state id=\"%s\">\n", id)
+		for _, t := range transitions {
+			b.WriteString(t)
+		}
+		b.WriteString("  </state>\n")
+	}
+	b.WriteString("</scxml>\n")
+
+	if len(unsupported) > 0 && !opts.IgnoreUnsupported {
+		return &UnsupportedSCXMLErr{Constructs: unsupported}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// xmlEscape Escape text for attribute values
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
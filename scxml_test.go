@@ -0,0 +1,156 @@
+package fsm
+
+import (
+	"bytes"
+	"gotest.tools/v3/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const playerSCXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scxml xmlns="http://www.w3.org/2005/07/scxml" version="1.0" initial="on">
+  <state id="on">
+    <initial><transition target="playing"/></initial>
+    <transition event="power" target="off"/>
+    <state id="playing">
+      <transition event="pause" target="paused"/>
+      <transition event="volume"/>
+    </state>
+    <state id="paused">
+      <transition event="pause play" target="playing"/>
+      <transition event="power" target="standby"/>
+    </state>
+  </state>
+  <state id="standby">
+    <transition event="power" target="on"/>
+  </state>
+  <final id="off"/>
+</scxml>`
+
+func TestSCXMLConfig(t *testing.T) {
+
+	c, err := NewSCXMLConfig(strings.NewReader(playerSCXML))
+	assert.NilError(t, err)
+	g, err := c.NewG()
+	assert.NilError(t, err)
+	assert.Equal(t, c.Initial(), "playing")
+	assert.Equal(t, len(c.Unsupported()), 0)
+	assert.Equal(t, g.VertexByState("paused").StoreVal(), "on")
+	assert.Equal(t, g.VertexByState("off").StoreVal(), "")
+	assert.Assert(t, g.VertexByState("on") == nil) // Compound states are flattened
+
+	testFSM := NewFsmByG(g, c.Initial())
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{event: "volume", want: "playing"},
+		{event: "pause", want: "paused"},
+		{event: "play", want: "playing"},
+		{event: "pause", want: "paused"},
+		{event: "power", want: "standby"}, // Child shadows parent
+		{event: "power", want: "playing"}, // Enter initial child
+		{event: "power", want: "off"},     // Inherited from parent
+	}
+	for _, tt := range tests {
+		e, err := testFSM.Trigger(tt.event)
+		assert.NilError(t, err)
+		assert.Equal(t, e.ToState(), tt.want)
+	}
+}
+
+func TestSCXMLConfig_Unsupported(t *testing.T) {
+
+	doc := `<scxml xmlns="http://www.w3.org/2005/07/scxml" version="1.0">
+  <datamodel><data id="n" expr="0"/></datamodel>
+  <state id="a">
+    <onentry><log expr="'hi'"/></onentry>
+    <transition event="go" cond="n &gt; 0" target="b"/>
+    <transition event="go" target="c"/>
+  </state>
+  <parallel id="b"/>
+  <state id="c"/>
+</scxml>`
+
+	c, err := NewSCXMLConfig(strings.NewReader(doc))
+	assert.NilError(t, err)
+	_, err = c.NewG()
+	unsupported, ok := err.(*UnsupportedSCXMLErr)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, unsupported.Constructs, []string{
+		"<datamodel> in <scxml>",
+		"<onentry> in state a",
+		"<parallel> in <scxml>",
+		"cond on transition go in state a",
+	})
+
+	c.IgnoreUnsupported = true
+	g, err := c.NewG()
+	assert.NilError(t, err)
+	edge, err := g.NextEdge("a", "go")
+	assert.NilError(t, err)
+	assert.Equal(t, edge.ToV().StateVal(), "c")
+
+	_, err = NewSCXMLConfig(strings.NewReader(`<state id="a"/>`))
+	_, ok = err.(*InvalidSCXMLErr)
+	assert.Assert(t, ok)
+}
+
+func TestGraph_WriteSCXML(t *testing.T) {
+
+	c, _ := NewSCXMLConfig(strings.NewReader(playerSCXML))
+	g, err := c.NewG()
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	assert.DeepEqual(t, c.Final(), []string{"off"})
+	assert.NilError(t, g.WriteSCXML(&buf, &SCXMLExportOpts[string]{Initial: c.Initial(), Final: c.Final()}))
+	assert.Assert(t, strings.Contains(buf.String(), `<final id="off"/>`), buf.String())
+
+	// Round trip keeps every transition
+	back, err := NewSCXMLConfig(&buf)
+	assert.NilError(t, err)
+	g2, err := back.NewG()
+	assert.NilError(t, err)
+	assert.Equal(t, back.Initial(), "playing")
+	assert.DeepEqual(t, back.Final(), c.Final())
+	assert.Equal(t, len(g2.ItoV()), len(g.ItoV()))
+	for _, v := range g.ItoV() {
+		if g.Adj()[v.Idx()] == nil {
+			continue
+		}
+		for _, e := range g.Adj()[v.Idx()].EList() {
+			e2, err := g2.NextEdge(v.StateVal(), e.EventVal())
+			assert.NilError(t, err)
+			assert.Equal(t, e2.ToV().StateVal(), e.ToV().StateVal())
+			assert.Equal(t, e2.Internal(), e.Internal())
+		}
+	}
+
+	// Timeouts can not be expressed
+	tg, _ := timeoutFac.NewG()
+	buf.Reset()
+	err = tg.WriteSCXML(&buf, &SCXMLExportOpts[nodeState]{Initial: initial})
+	_, ok := err.(*UnsupportedSCXMLErr)
+	assert.Assert(t, ok)
+	assert.Equal(t, buf.Len(), 0)
+	assert.NilError(t, tg.WriteSCXML(&buf, &SCXMLExportOpts[nodeState]{Initial: initial, IgnoreUnsupported: true}))
+	assert.Assert(t, strings.Contains(buf.String(), `<state id="4"/>`), buf.String())
+
+	// A state whose only transition was dropped is not final
+	wg, _ := (&DefConfig[string, string, NA, NA]{
+		DescList: []*DescCell[string, string, NA, NA]{
+			{EventVal: "go", FromState: []string{"idle"}, ToState: "waiting"},
+			{EventVal: "expire", FromState: []string{"waiting"}, ToState: "idle", After: time.Minute},
+		},
+	}).NewG()
+	buf.Reset()
+	assert.NilError(t, wg.WriteSCXML(&buf, &SCXMLExportOpts[string]{Initial: "idle", IgnoreUnsupported: true}))
+	assert.Assert(t, strings.Contains(buf.String(), `<state id="waiting"/>`), buf.String())
+	assert.Assert(t, !strings.Contains(buf.String(), "<final"), buf.String())
+
+	// Final states must not have transitions
+	err = wg.WriteSCXML(&buf, &SCXMLExportOpts[string]{Initial: "idle", Final: []string{"idle"}})
+	assert.ErrorContains(t, err, "transitions of final state idle")
+}
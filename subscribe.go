@@ -0,0 +1,158 @@
+package fsm
+
+import (
+	"sync"
+	"time"
+)
+
+// SlowConsumerPolicy What to do when a subscriber's buffer is full
+// Trigger never blocks on subscribers
+type SlowConsumerPolicy int
+
+const (
+	SlowConsumerDropNewest SlowConsumerPolicy = iota // Discard the notice being published
+	SlowConsumerDropOldest                           // Discard the oldest buffered notice to make room
+	SlowConsumerCancel                               // Cancel the subscription and close its channel
+)
+
+const defaultSubscribeBufSize = 16
+
+type (
+	// TransitionNotice A completed transition
+	TransitionNotice[T, S comparable] struct {
+		From     T
+		To       T
+		Event    S
+		Args     []interface{}
+		Time     time.Time
+		Internal bool // From == To and the state was not exited
+	}
+
+	// SubscribeFilter Decide which transitions a subscriber receives. Empty list matches everything
+	SubscribeFilter[T, S comparable] struct {
+		FromStates []T                // Optional. Match any of these from states
+		ToStates   []T                // Optional. Match any of these to states
+		Events     []S                // Optional. Match any of these events
+		BufSize    int                // Channel buffer size. Use default size if <= 0
+		Policy     SlowConsumerPolicy // Applied when channel buffer is full
+	}
+
+	// subscriber One Subscribe call
+	subscriber[T, S comparable] struct {
+		ch     chan TransitionNotice[T, S]
+		filter SubscribeFilter[T, S]
+		once   sync.Once
+	}
+)
+
+// Subscribe receive notices of completed transitions
+// Notices are delivered after afterStateChange returns without error. Call cancel to release the subscription
+func (f *FSM[T, S, U, V]) Subscribe(filter *SubscribeFilter[T, S]) (<-chan TransitionNotice[T, S], func()) {
+	sub := &subscriber[T, S]{}
+	if filter != nil {
+		sub.filter = *filter
+	}
+	size := sub.filter.BufSize
+	if size <= 0 {
+		size = defaultSubscribeBufSize
+	}
+	sub.ch = make(chan TransitionNotice[T, S], size)
+
+	f.subMutex.Lock()
+	if f.subs == nil {
+		f.subs = make(map[*subscriber[T, S]]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	f.subMutex.Unlock()
+
+	return sub.ch, func() {
+		f.unsubscribe(sub)
+	}
+}
+
+// unsubscribe Remove subscriber and close its channel. Safe to call more than once
+func (f *FSM[T, S, U, V]) unsubscribe(sub *subscriber[T, S]) {
+	sub.once.Do(func() {
+		f.subMutex.Lock()
+		delete(f.subs, sub)
+		close(sub.ch)
+		f.subMutex.Unlock()
+	})
+}
+
+// publish Deliver a notice to all matching subscribers without blocking
+func (f *FSM[T, S, U, V]) publish(e *Event[T, S, U, V]) {
+	f.subMutex.RLock()
+	if len(f.subs) == 0 {
+		f.subMutex.RUnlock()
+		return
+	}
+	n := TransitionNotice[T, S]{
+		From:     e.FromState(),
+		To:       e.ToState(),
+		Event:    e.eventVal,
+		Args:     e.args,
+		Time:     f.now(),
+		Internal: e.Internal(),
+	}
+	var slow []*subscriber[T, S]
+	for sub := range f.subs {
+		if !sub.match(&n) {
+			continue
+		}
+		if !sub.offer(n) {
+			slow = append(slow, sub)
+		}
+	}
+	f.subMutex.RUnlock()
+
+	for _, sub := range slow {
+		f.unsubscribe(sub)
+	}
+}
+
+// match Whether notice passes the filter
+func (s *subscriber[T, S]) match(n *TransitionNotice[T, S]) bool {
+	return matchAny(s.filter.FromStates, n.From) &&
+		matchAny(s.filter.ToStates, n.To) &&
+		matchAny(s.filter.Events, n.Event)
+}
+
+// offer Try to deliver notice. Return false if the subscriber should be canceled
+func (s *subscriber[T, S]) offer(n TransitionNotice[T, S]) bool {
+	select {
+	case s.ch <- n:
+		return true
+	default:
+	}
+
+	switch s.filter.Policy {
+	case SlowConsumerDropOldest:
+		select {
+		case <-s.ch:
+		default:
+		}
+		select {
+		case s.ch <- n:
+		default:
+		}
+	case SlowConsumerCancel:
+		return false
+	}
+	return true
+}
+
+// matchOne Whether k is in list
+func matchOne[K comparable](list []K, k K) bool {
+	for _, v := range list {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny Whether k is in list. Empty list matches everything
+func matchAny[K comparable](list []K, k K) bool {
+	return len(list) == 0 || matchOne(list, k)
+}
@@ -0,0 +1,91 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestFSM_Subscribe(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+
+	all, cancelAll := testFSM.Subscribe(nil)
+	defer cancelAll()
+	canceled, cancelCanceled := testFSM.Subscribe(&SubscribeFilter[nodeState, eventVal]{
+		ToStates: []nodeState{canceled},
+	})
+	defer cancelCanceled()
+
+	tests := generateNonErrorTests()
+	for _, tt := range tests {
+		_, err := testFSM.Trigger(tt.eventName, tt.eventName)
+		assert.NilError(t, err)
+	}
+
+	for _, tt := range tests {
+		n := <-all
+		assert.Equal(t, n.Event, tt.eventName)
+		assert.Equal(t, n.From, tt.wantW.fromState)
+		assert.Equal(t, n.To, tt.wantW.toState)
+		assert.DeepEqual(t, n.Args, []interface{}{tt.eventName})
+	}
+	assert.Equal(t, len(all), 0)
+
+	// Two cancelEvent in generateNonErrorTests
+	assert.Equal(t, len(canceled), 2)
+	for i := 0; i < 2; i += 1 {
+		n := <-canceled
+		assert.Equal(t, n.Event, eventVal(cancelEvent))
+	}
+}
+
+func TestFSM_Subscribe_SlowConsumer(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		policy    SlowConsumerPolicy
+		wantFirst eventVal
+		wantOpen  bool
+	}{
+		{
+			name:      "drop newest",
+			policy:    SlowConsumerDropNewest,
+			wantFirst: payEvent,
+			wantOpen:  true,
+		},
+		{
+			name:      "drop oldest",
+			policy:    SlowConsumerDropOldest,
+			wantFirst: receiveEvent,
+			wantOpen:  true,
+		},
+		{
+			name:      "cancel",
+			policy:    SlowConsumerCancel,
+			wantFirst: payEvent,
+			wantOpen:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+			ch, cancel := testFSM.Subscribe(&SubscribeFilter[nodeState, eventVal]{
+				BufSize: 1,
+				Policy:  tt.policy,
+			})
+			defer cancel()
+			for _, e := range []eventVal{payEvent, deliverEvent, receiveEvent} {
+				_, err := testFSM.Trigger(e)
+				assert.NilError(t, err)
+			}
+			n := <-ch
+			assert.Equal(t, n.Event, tt.wantFirst)
+			open := true
+			select {
+			case _, open = <-ch:
+			default:
+			}
+			assert.Equal(t, open, tt.wantOpen)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package fsm
+
+import (
+	"sort"
+)
+
+// sequenceKind What GenerateTestSequences covers
+type sequenceKind int
+
+const (
+	sequenceAllStates sequenceKind = iota
+	sequenceAllTransitions
+	sequenceKPaths
+)
+
+type (
+	// SequenceStrategy Build with AllStates, AllTransitions or KPaths
+	SequenceStrategy struct {
+		kind sequenceKind
+		k    int
+	}
+
+	// TestSequence Events to trigger from the initial state and the state expected after each of them
+	TestSequence[T, S comparable] struct {
+		Events []S
+		States []T
+	}
+)
+
+// AllStates Shortest sequences entering every reachable state at least once
+func AllStates() SequenceStrategy {
+	return SequenceStrategy{kind: sequenceAllStates}
+}
+
+// AllTransitions Sequences taking every reachable transition at least once
+// It is a greedy transition tour: always walk to the nearest transition not taken yet, restart when none is reachable
+func AllTransitions() SequenceStrategy {
+	return SequenceStrategy{kind: sequenceAllTransitions}
+}
+
+// KPaths Every sequence of k events, and shorter ones ending in a state that accepts nothing
+// The number of sequences grows exponentially with k. GenerateTestSequences rejects k < 1
+func KPaths(k int) SequenceStrategy {
+	return SequenceStrategy{kind: sequenceKPaths, k: k}
+}
+
+// GenerateTestSequences plan event sequences on g from initState
+// Only transitions whose target is known from the graph alone are planned. Transitions into pseudo-states,
+// stack transitions and events of orthogonal regions depend on guards or runtime state, so they are left out
+func GenerateTestSequences[T, S comparable, U, V any](g *Graph[T, S, U, V], initState T, strategy SequenceStrategy) ([]*TestSequence[T, S], error) {
+	initV := g.VertexByState(initState)
+	if initV == nil {
+		return nil, &StateNotExistErr[T]{State: initState}
+	}
+	switch strategy.kind {
+	case sequenceAllStates:
+		return g.allStates(initV), nil
+	case sequenceAllTransitions:
+		return g.allTransitions(initV), nil
+	default:
+		if strategy.k < 1 {
+			return nil, &InvalidPathLengthErr{K: strategy.k}
+		}
+		return g.kPaths(initV, strategy.k), nil
+	}
+}
+
+// plannedEdges Edges leaving v that GenerateTestSequences can plan
+func (g *Graph[T, S, U, V]) plannedEdges(v *Vertex[T, V]) []*Edge[T, S, U, V] {
+	if g.adj[v.idx] == nil {
+		return nil
+	}
+	var resp []*Edge[T, S, U, V]
+	for _, e := range g.adj[v.idx].eList {
+		if e.branch || e.stackOp != StackNone || e.toV.Pseudo() {
+			continue
+		}
+		// Wildcard expansion may leave several edges for one event, only the first is ever taken
+		if first, err := g.NextEdge(v.stateVal, e.eventVal); err != nil || first != e {
+			continue
+		}
+		resp = append(resp, e)
+	}
+	return resp
+}
+
+// shortestPaths Breadth first from v. Return the edge used to reach each vertex idx and the visiting order
+func (g *Graph[T, S, U, V]) shortestPaths(v *Vertex[T, V]) (map[int]*Edge[T, S, U, V], []int) {
+	via := map[int]*Edge[T, S, U, V]{v.idx: nil}
+	order := []int{v.idx}
+	for i := 0; i < len(order); i += 1 {
+		for _, e := range g.plannedEdges(g.itoV[order[i]]) {
+			if _, ok := via[e.toV.idx]; !ok {
+				via[e.toV.idx] = e
+				order = append(order, e.toV.idx)
+			}
+		}
+	}
+	return via, order
+}
+
+// pathOf Edges from the root of via to idx
+func pathOf[T, S comparable, U, V any](via map[int]*Edge[T, S, U, V], idx int) []*Edge[T, S, U, V] {
+	var resp []*Edge[T, S, U, V]
+	for e := via[idx]; e != nil; e = via[e.fromV.idx] {
+		resp = append(resp, e)
+	}
+	for i, j := 0, len(resp)-1; i < j; i, j = i+1, j-1 {
+		resp[i], resp[j] = resp[j], resp[i]
+	}
+	return resp
+}
+
+// allStates Deepest states first, so that their paths cover the states on the way
+func (g *Graph[T, S, U, V]) allStates(initV *Vertex[T, V]) []*TestSequence[T, S] {
+	via, order := g.shortestPaths(initV)
+	depth := make(map[int]int, len(order))
+	for _, idx := range order {
+		depth[idx] = len(pathOf(via, idx))
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return depth[order[i]] > depth[order[j]]
+	})
+
+	var resp []*TestSequence[T, S]
+	covered := map[int]bool{initV.idx: true}
+	for _, idx := range order {
+		if covered[idx] {
+			continue
+		}
+		path := pathOf(via, idx)
+		for _, e := range path {
+			covered[e.toV.idx] = true
+		}
+		resp = append(resp, sequenceOf(path))
+	}
+	return resp
+}
+
+// allTransitions Greedy transition tour
+func (g *Graph[T, S, U, V]) allTransitions(initV *Vertex[T, V]) []*TestSequence[T, S] {
+	taken := make(map[*Edge[T, S, U, V]]bool)
+	var resp []*TestSequence[T, S]
+	for {
+		var tour []*Edge[T, S, U, V]
+		curr := initV
+		for {
+			next := g.nearestUntaken(curr, taken)
+			if next == nil {
+				break
+			}
+			for _, e := range next {
+				taken[e] = true
+			}
+			tour = append(tour, next...)
+			curr = next[len(next)-1].toV
+		}
+		if len(tour) == 0 {
+			return resp
+		}
+		resp = append(resp, sequenceOf(tour))
+	}
+}
+
+// nearestUntaken Shortest path from v ending with an edge not taken yet. Nil if none is reachable
+func (g *Graph[T, S, U, V]) nearestUntaken(v *Vertex[T, V], taken map[*Edge[T, S, U, V]]bool) []*Edge[T, S, U, V] {
+	via, order := g.shortestPaths(v)
+	for _, idx := range order {
+		for _, e := range g.plannedEdges(g.itoV[idx]) {
+			if !taken[e] {
+				return append(pathOf(via, idx), e)
+			}
+		}
+	}
+	return nil
+}
+
+// kPaths Depth first enumeration of paths with k edges
+func (g *Graph[T, S, U, V]) kPaths(initV *Vertex[T, V], k int) []*TestSequence[T, S] {
+	var resp []*TestSequence[T, S]
+	var path []*Edge[T, S, U, V]
+	var dfs func(v *Vertex[T, V])
+	dfs = func(v *Vertex[T, V]) {
+		edges := g.plannedEdges(v)
+		if len(path) == k || len(edges) == 0 {
+			if len(path) > 0 {
+				resp = append(resp, sequenceOf(path))
+			}
+			return
+		}
+		for _, e := range edges {
+			path = append(path, e)
+			dfs(e.toV)
+			path = path[:len(path)-1]
+		}
+	}
+	dfs(initV)
+	return resp
+}
+
+// sequenceOf Events and expected states of path
+func sequenceOf[T, S comparable, U, V any](path []*Edge[T, S, U, V]) *TestSequence[T, S] {
+	s := &TestSequence[T, S]{}
+	for _, e := range path {
+		s.Events = append(s.Events, e.eventVal)
+		s.States = append(s.States, e.toV.stateVal)
+	}
+	return s
+}
+
+// RunSequences replay every sequence on a new FSM from newFSM
+// After each event the state is compared with the expected one, then check is called if not nil.
+// The first failure is returned as SequenceFailedErr
+func RunSequences[T, S comparable, U, V any](seqs []*TestSequence[T, S], newFSM func() (*FSM[T, S, U, V], error), check func(f *FSM[T, S, U, V], e *Event[T, S, U, V]) error) error {
+	for i, seq := range seqs {
+		f, err := newFSM()
+		if err != nil {
+			return &SequenceFailedErr{Sequence: i, Step: -1, Err: err}
+		}
+		for j, ev := range seq.Events {
+			e, err := f.Trigger(ev)
+			if err == nil && f.CurrState() != seq.States[j] {
+				err = &UnexpectedStateErr[T]{Want: seq.States[j], Got: f.CurrState()}
+			}
+			if err == nil && check != nil {
+				err = check(f, e)
+			}
+			if err != nil {
+				return &SequenceFailedErr{Sequence: i, Step: j, Err: err}
+			}
+		}
+	}
+	return nil
+}
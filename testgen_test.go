@@ -0,0 +1,80 @@
+package fsm
+
+import (
+	"errors"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestGenerateTestSequences(t *testing.T) {
+
+	g, _ := descFac.NewG()
+
+	seqs, err := GenerateTestSequences(g, initial, AllStates())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, seqs, []*TestSequence[nodeState, eventVal]{
+		{Events: []eventVal{payEvent, deliverEvent, receiveEvent}, States: []nodeState{paid, delivering, done}},
+		{Events: []eventVal{payEvent, cancelEvent}, States: []nodeState{paid, canceled}},
+	})
+
+	seqs, err = GenerateTestSequences(g, initial, KPaths(3))
+	assert.NilError(t, err)
+	assert.Equal(t, len(seqs), 3)
+	assert.DeepEqual(t, seqs[1].Events, []eventVal{payEvent, deliverEvent, cancelEvent})
+
+	_, err = GenerateTestSequences(g, nodeState(100), AllStates())
+	assert.ErrorType(t, err, &StateNotExistErr[nodeState]{})
+
+	for _, k := range []int{0, -1} {
+		_, err = GenerateTestSequences(g, initial, KPaths(k))
+		assert.ErrorType(t, err, &InvalidPathLengthErr{})
+	}
+}
+
+func TestGenerateTestSequences_AllTransitions(t *testing.T) {
+
+	g, _ := descFac.NewG()
+	seqs, err := GenerateTestSequences(g, initial, AllTransitions())
+	assert.NilError(t, err)
+	assert.Equal(t, len(seqs), 1)
+
+	// Replaying the tour covers the whole graph
+	cov := NewCoverage(g)
+	err = RunSequences(seqs, func() (*FSM[nodeState, eventVal, edgeVal, nodeVal], error) {
+		f := NewFsmByG(g, initial)
+		cov.Attach(f)
+		return f, nil
+	}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, cov.Report().Percent(), float64(100))
+}
+
+func TestRunSequences(t *testing.T) {
+
+	g, _ := descFac.NewG()
+	seqs, _ := GenerateTestSequences(g, initial, AllStates())
+	newFSM := func() (*FSM[nodeState, eventVal, edgeVal, nodeVal], error) {
+		return NewFsmByG(g, initial), nil
+	}
+
+	// User assertion fails on the second sequence
+	errCanceled := errors.New("canceled")
+	err := RunSequences(seqs, newFSM, func(f *FSM[nodeState, eventVal, edgeVal, nodeVal], e *Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+		if f.CurrState() == canceled {
+			return errCanceled
+		}
+		return nil
+	})
+	var failed *SequenceFailedErr
+	assert.Assert(t, errors.As(err, &failed))
+	assert.Equal(t, failed.Sequence, 1)
+	assert.Equal(t, failed.Step, 1)
+	assert.Assert(t, errors.Is(err, errCanceled))
+
+	// Expected states are checked
+	seqs[0].States[2] = canceled
+	err = RunSequences(seqs, newFSM, nil)
+	var unexpected *UnexpectedStateErr[nodeState]
+	assert.Assert(t, errors.As(err, &unexpected))
+	assert.Equal(t, unexpected.Got, nodeState(done))
+}
@@ -0,0 +1,310 @@
+package fsm
+
+import (
+	"time"
+)
+
+// scheduled One event waiting to be triggered by Clock
+type scheduled[T, S comparable] struct {
+	*TimerRecord[T, S]
+	timer Timer
+}
+
+// Schedule trigger eventVal at the given time
+// Unlike state timeouts, scheduled events are not canceled by state changes. Use Cancel to abort
+func (f *FSM[T, S, U, V]) Schedule(eventVal S, at time.Time, args ...interface{}) uint64 {
+	f.timerMutex.Lock()
+	defer f.unlockTimers()
+	return f.arm(&TimerRecord[T, S]{
+		EventVal: eventVal,
+		At:       at,
+		Args:     args,
+	})
+}
+
+// Cancel a scheduled event or state timeout by id. Return false if it already fired or was canceled
+func (f *FSM[T, S, U, V]) Cancel(id uint64) bool {
+	f.timerMutex.Lock()
+	defer f.unlockTimers()
+	s, ok := f.timers[id]
+	if !ok {
+		return false
+	}
+	s.timer.Stop()
+	f.forget(id)
+	return true
+}
+
+// RestoreTimers re-arm timers recorded in TimerStore, usually right after process start
+// FSM should already be in the persisted state. Records of state timeouts bound to another state are dropped.
+// If no state timeout of current state, or of one of its regions, is recorded at all, they are armed from now on
+func (f *FSM[T, S, U, V]) RestoreTimers(policy CatchUpPolicy) error {
+	if f.timerStore == nil {
+		return &TimerStoreNotSetErr{}
+	}
+
+	if !f.noSync {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+	}
+
+	records, err := f.timerStore.Load()
+	if err != nil {
+		return err
+	}
+
+	f.timerMutex.Lock()
+	defer f.unlockTimers()
+
+	// Drop timeouts armed from construction time
+	for id, s := range f.timers {
+		if s.StateBound {
+			s.timer.Stop()
+			delete(f.timers, id)
+		}
+	}
+
+	// Current state and its regions
+	snap := f.load()
+	live := append([]T{snap.Curr}, snap.Regions...)
+	restored := make(map[T]bool, len(live))
+	for _, s := range live {
+		restored[s] = false
+	}
+	now := f.getClock().Now()
+	for _, r := range records {
+		if r.ID > f.timerSeq {
+			f.timerSeq = r.ID
+		}
+		if _, ok := f.timers[r.ID]; ok {
+			continue
+		}
+		_, bound := restored[r.State]
+		if r.StateBound && bound {
+			restored[r.State] = true
+		}
+		if (r.StateBound && !bound) || (policy == CatchUpSkip && r.At.Before(now)) {
+			if err := f.timerStore.Delete(r.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		f.arm(r)
+	}
+
+	for _, s := range live {
+		if !restored[s] {
+			f.armStateTimeouts(s)
+			restored[s] = true
+		}
+	}
+	return nil
+}
+
+// arm Register an event in clock and timer store. Caller must hold timerMutex
+// Overdue events fire as soon as possible
+func (f *FSM[T, S, U, V]) arm(r *TimerRecord[T, S]) uint64 {
+	if f.timers == nil {
+		f.timers = make(map[uint64]*scheduled[T, S])
+	}
+	if r.ID == 0 {
+		f.timerSeq += 1
+		r.ID = f.timerSeq
+	} else if r.ID > f.timerSeq {
+		f.timerSeq = r.ID
+	}
+	id := r.ID
+	s := &scheduled[T, S]{TimerRecord: r}
+	f.timers[id] = s
+	if f.timerStore != nil {
+		if err := f.timerStore.Save(r); err != nil {
+			f.reportTimerErr(err)
+		}
+	}
+	clock := f.getClock()
+	s.timer = clock.AfterFunc(r.At.Sub(clock.Now()), func() {
+		f.fire(id)
+	})
+	return id
+}
+
+// forget Remove an event from timers and timer store. Caller must hold timerMutex
+func (f *FSM[T, S, U, V]) forget(id uint64) {
+	delete(f.timers, id)
+	if f.timerStore != nil {
+		if err := f.timerStore.Delete(id); err != nil {
+			f.reportTimerErr(err)
+		}
+	}
+}
+
+// fire Trigger a due event if it is still pending
+// Holding the FSM lock before checking ensures a state timeout never fires in a state it doesn't belong to
+func (f *FSM[T, S, U, V]) fire(id uint64) {
+	if !f.noSync {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+	}
+
+	f.timerMutex.Lock()
+	s, ok := f.timers[id]
+	if ok {
+		f.forget(id)
+	}
+	f.unlockTimers()
+	if !ok {
+		return
+	}
+
+	if _, err := f.trigger(s.EventVal, s.Args); err != nil {
+		f.timerMutex.Lock()
+		onTimerErr := f.onTimerErr
+		f.timerMutex.Unlock()
+		if onTimerErr != nil {
+			onTimerErr(err)
+		}
+	}
+}
+
+// armStateTimers Cancel timeouts of the state left, arm timeouts of current state and of its regions
+func (f *FSM[T, S, U, V]) armStateTimers() {
+	f.timerMutex.Lock()
+	defer f.unlockTimers()
+
+	for id, s := range f.timers {
+		if s.StateBound {
+			s.timer.Stop()
+			f.forget(id)
+		}
+	}
+	snap := f.load()
+	f.armStateTimeouts(snap.Curr)
+	for _, r := range snap.Regions {
+		f.armStateTimeouts(r)
+	}
+}
+
+// armRegionTimers Cancel timeouts of the state a region left, arm timeouts of the state it entered
+func (f *FSM[T, S, U, V]) armRegionTimers(left, entered T) {
+	f.timerMutex.Lock()
+	defer f.unlockTimers()
+
+	for id, s := range f.timers {
+		if s.StateBound && s.State == left {
+			s.timer.Stop()
+			f.forget(id)
+		}
+	}
+	f.armStateTimeouts(entered)
+}
+
+// armStateTimeouts Arm timeouts of state. Caller must hold timerMutex
+func (f *FSM[T, S, U, V]) armStateTimeouts(state T) {
+	v := f.g.VertexByState(state)
+	if v == nil || f.g.adj[v.idx] == nil {
+		return
+	}
+	now := f.getClock().Now()
+	for _, e := range f.g.adj[v.idx].eList {
+		if e.after > 0 {
+			f.arm(&TimerRecord[T, S]{
+				State:      state,
+				StateBound: true,
+				EventVal:   e.eventVal,
+				At:         now.Add(e.after),
+			})
+		}
+	}
+}
+
+// reportTimerErr Errors of timers have no caller to return to. Caller must hold timerMutex
+// They are passed to onTimerErr by unlockTimers, so the handler may call back into the FSM
+func (f *FSM[T, S, U, V]) reportTimerErr(err error) {
+	f.timerErrs = append(f.timerErrs, err)
+}
+
+// unlockTimers Release timerMutex, then pass errors reported meanwhile to onTimerErr
+func (f *FSM[T, S, U, V]) unlockTimers() {
+	errs, onTimerErr := f.timerErrs, f.onTimerErr
+	f.timerErrs = nil
+	f.timerMutex.Unlock()
+	if onTimerErr == nil {
+		return
+	}
+	for _, err := range errs {
+		onTimerErr(err)
+	}
+}
+
+// getClock Use real clock by default. Caller must hold timerMutex
+func (f *FSM[T, S, U, V]) getClock() Clock {
+	if f.clock == nil {
+		return realClock{}
+	}
+	return f.clock
+}
+
+func (f *FSM[T, S, U, V]) Clock() Clock {
+	f.timerMutex.Lock()
+	defer f.timerMutex.Unlock()
+	return f.clock
+}
+
+// SetClock replace time source. Timeouts of current state are re-armed with the new clock
+// Events scheduled before keep using the old clock, so set it before calling Schedule.
+// Use NewFsmByGWithClock to avoid arming timeouts of the initial state on the real clock first
+func (f *FSM[T, S, U, V]) SetClock(clock Clock) {
+	if !f.noSync {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+	}
+	f.timerMutex.Lock()
+	f.clock = clock
+	f.timerMutex.Unlock()
+	f.armStateTimers()
+}
+
+// now Current time of the clock
+func (f *FSM[T, S, U, V]) now() time.Time {
+	f.timerMutex.Lock()
+	defer f.timerMutex.Unlock()
+	return f.getClock().Now()
+}
+
+func (f *FSM[T, S, U, V]) OnTimerErr() func(error) {
+	f.timerMutex.Lock()
+	defer f.timerMutex.Unlock()
+	return f.onTimerErr
+}
+
+// SetOnTimerErr receive errors of events triggered by timers and errors of TimerStore
+func (f *FSM[T, S, U, V]) SetOnTimerErr(onTimerErr func(error)) {
+	f.timerMutex.Lock()
+	defer f.timerMutex.Unlock()
+	f.onTimerErr = onTimerErr
+}
+
+func (f *FSM[T, S, U, V]) TimerStore() TimerStore[T, S] {
+	return f.timerStore
+}
+
+// SetTimerStore record pending timers from now on. Call RestoreTimers to re-arm recorded ones
+// New timer ids continue after the largest recorded one, so records of a previous process are never overwritten
+func (f *FSM[T, S, U, V]) SetTimerStore(timerStore TimerStore[T, S]) {
+	f.timerMutex.Lock()
+	defer f.unlockTimers()
+	f.timerStore = timerStore
+	if timerStore == nil {
+		return
+	}
+	records, err := timerStore.Load()
+	if err != nil {
+		f.reportTimerErr(err)
+		return
+	}
+	for _, r := range records {
+		if r.ID > f.timerSeq {
+			f.timerSeq = r.ID
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+var timeoutFac = &DefConfig[nodeState, eventVal, edgeVal, nodeVal]{
+	DescList: []*DescCell[nodeState, eventVal, edgeVal, nodeVal]{
+		{
+			EventVal:  payEvent,
+			FromState: []nodeState{initial},
+			ToState:   paid,
+		},
+		{
+			EventVal:  deliverEvent,
+			FromState: []nodeState{paid},
+			ToState:   delivering,
+		},
+		{
+			EventVal:  cancelEvent,
+			FromState: []nodeState{paid},
+			ToState:   canceled,
+			After:     30 * time.Second, // Unpaid order expires
+		},
+	},
+}
+
+func TestFSM_StateTimeout(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	clock := NewFakeClock(time.Unix(0, 0))
+	testFSM.SetClock(clock)
+
+	t.Run("fire", func(t *testing.T) {
+		_, err := testFSM.Trigger(payEvent)
+		assert.NilError(t, err)
+		clock.Advance(29 * time.Second)
+		assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+		clock.Advance(time.Second)
+		assert.Equal(t, testFSM.CurrState(), nodeState(canceled))
+		assert.Equal(t, clock.Pending(), 0)
+	})
+
+	t.Run("canceled on leaving", func(t *testing.T) {
+		testFSM.ForceSetCurrState(paid)
+		assert.Equal(t, clock.Pending(), 1)
+		clock.Advance(10 * time.Second)
+		_, err := testFSM.Trigger(deliverEvent)
+		assert.NilError(t, err)
+		assert.Equal(t, clock.Pending(), 0)
+		clock.Advance(time.Minute)
+		assert.Equal(t, testFSM.CurrState(), nodeState(delivering))
+	})
+}
+
+func TestFSM_Schedule(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	clock := NewFakeClock(time.Unix(0, 0))
+	testFSM.SetClock(clock)
+
+	var timerErr error
+	testFSM.SetOnTimerErr(func(err error) {
+		timerErr = err
+	})
+
+	// Canceled before due
+	id := testFSM.Schedule(payEvent, clock.Now().Add(time.Second))
+	assert.Assert(t, testFSM.Cancel(id))
+	assert.Assert(t, !testFSM.Cancel(id))
+	clock.Advance(time.Second)
+	assert.Equal(t, testFSM.CurrState(), nodeState(initial))
+
+	// Scheduled events survive state changes
+	testFSM.Schedule(payEvent, clock.Now().Add(10*time.Second))
+	testFSM.Schedule(deliverEvent, clock.Now().Add(20*time.Second))
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, testFSM.CurrState(), nodeState(paid))
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, testFSM.CurrState(), nodeState(delivering))
+
+	// Invalid event reported
+	testFSM.Schedule(payEvent, clock.Now())
+	clock.Advance(0)
+	_, ok := timerErr.(*InvalidEventErr[nodeState, eventVal])
+	assert.Assert(t, ok)
+}
+
+func TestFSM_NewFsmByGWithClock(t *testing.T) {
+
+	g, _ := timeoutFac.NewG()
+	t0 := time.Unix(0, 0)
+	clock := NewFakeClock(t0)
+	testFSM := NewFsmByGWithClock(g, paid, clock)
+	assert.Equal(t, clock.Pending(), 1)
+
+	// Notices are stamped by the same clock
+	notices, cancel := testFSM.Subscribe(nil)
+	defer cancel()
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, testFSM.CurrState(), nodeState(canceled))
+	n := <-notices
+	assert.Equal(t, n.Time, t0.Add(30*time.Second))
+}
+
+func TestFSM_SetClock_Concurrent(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	clock := NewFakeClock(time.Unix(0, 0))
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		testFSM.SetClock(clock)
+	}()
+	go func() {
+		defer wg.Done()
+		testFSM.SetOnTimerErr(func(error) {})
+	}()
+	go func() {
+		defer wg.Done()
+		testFSM.Cancel(testFSM.Schedule(payEvent, time.Unix(0, 0).Add(time.Hour)))
+	}()
+	wg.Wait()
+	assert.Assert(t, testFSM.Clock() == clock)
+	assert.Assert(t, testFSM.OnTimerErr() != nil)
+}
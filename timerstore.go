@@ -0,0 +1,177 @@
+package fsm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CatchUpPolicy What RestoreTimers does with timers whose deadline has passed
+type CatchUpPolicy int
+
+const (
+	CatchUpFire CatchUpPolicy = iota // Fire overdue timers immediately
+	CatchUpSkip                      // Drop overdue timers
+)
+
+type (
+	// TimerRecord Persisted form of a pending state timeout or scheduled event
+	TimerRecord[T, S comparable] struct {
+		ID         uint64        `json:"id"`
+		State      T             `json:"state"`       // State the timeout belongs to. Only meaningful if StateBound
+		StateBound bool          `json:"state_bound"` // True for state timeouts, false for FSM.Schedule
+		EventVal   S             `json:"event"`
+		At         time.Time     `json:"at"`   // Deadline
+		Args       []interface{} `json:"args"` // Must survive encoding if the store serializes records
+	}
+
+	// TimerStore Implement it to keep pending timers across restarts
+	// Methods are called while FSM holds its timer lock, so they must not call back into FSM
+	TimerStore[T, S comparable] interface {
+		Save(*TimerRecord[T, S]) error // Insert or replace record by ID
+		Delete(id uint64) error        // Deleting a missing record is not an error
+		Load() ([]*TimerRecord[T, S], error)
+	}
+
+	// MemTimerStore TimerStore in memory. Useful in tests or to share records between FSMs in one process
+	MemTimerStore[T, S comparable] struct {
+		records map[uint64]*TimerRecord[T, S]
+		mutex   sync.Mutex
+	}
+
+	// FileTimerStore TimerStore in a JSON file. The whole file is rewritten on every change
+	FileTimerStore[T, S comparable] struct {
+		path   string
+		mem    MemTimerStore[T, S]
+		loaded bool
+		mutex  sync.Mutex
+	}
+)
+
+// Ensure interface implement
+var (
+	_ TimerStore[struct{}, struct{}] = new(MemTimerStore[struct{}, struct{}])
+	_ TimerStore[struct{}, struct{}] = new(FileTimerStore[struct{}, struct{}])
+)
+
+// NewMemTimerStore new an empty MemTimerStore
+func NewMemTimerStore[T, S comparable]() *MemTimerStore[T, S] {
+	return &MemTimerStore[T, S]{}
+}
+
+func (s *MemTimerStore[T, S]) Save(r *TimerRecord[T, S]) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.records == nil {
+		s.records = make(map[uint64]*TimerRecord[T, S])
+	}
+	cp := *r
+	s.records[r.ID] = &cp
+	return nil
+}
+
+func (s *MemTimerStore[T, S]) Delete(id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+// Load records ordered by ID
+func (s *MemTimerStore[T, S]) Load() ([]*TimerRecord[T, S], error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := make([]*TimerRecord[T, S], 0, len(s.records))
+	for _, r := range s.records {
+		cp := *r
+		resp = append(resp, &cp)
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].ID < resp[j].ID
+	})
+	return resp, nil
+}
+
+// NewFileTimerStore new a FileTimerStore. The file is created on first change
+func NewFileTimerStore[T, S comparable](path string) *FileTimerStore[T, S] {
+	return &FileTimerStore[T, S]{path: path}
+}
+
+func (s *FileTimerStore[T, S]) Save(r *TimerRecord[T, S]) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	_ = s.mem.Save(r)
+	return s.flush()
+}
+
+func (s *FileTimerStore[T, S]) Delete(id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	_ = s.mem.Delete(id)
+	return s.flush()
+}
+
+func (s *FileTimerStore[T, S]) Load() ([]*TimerRecord[T, S], error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.mem.Load()
+}
+
+// load Read file once. Missing file means no record
+func (s *FileTimerStore[T, S]) load() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		var records []*TimerRecord[T, S]
+		if err := json.Unmarshal(data, &records); err != nil {
+			return err
+		}
+		for _, r := range records {
+			_ = s.mem.Save(r)
+		}
+	}
+	s.loaded = true
+	return nil
+}
+
+// flush Write all records to a temp file, then rename it over the old one
+func (s *FileTimerStore[T, S]) flush() error {
+	records, _ := s.mem.Load()
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
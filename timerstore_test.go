@@ -0,0 +1,142 @@
+package fsm
+
+import (
+	"errors"
+	"gotest.tools/v3/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFSM_RestoreTimers(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "timers.json")
+	t0 := time.Unix(0, 0)
+
+	// Process 1: enter paid, schedule deliverEvent, then "crash"
+	before, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	before.SetClock(NewFakeClock(t0))
+	before.SetTimerStore(NewFileTimerStore[nodeState, eventVal](path))
+	_, err := before.Trigger(payEvent)
+	assert.NilError(t, err)
+	before.Schedule(deliverEvent, t0.Add(time.Hour))
+
+	records, err := NewFileTimerStore[nodeState, eventVal](path).Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 2)
+
+	tests := []struct {
+		name      string
+		restartAt time.Duration
+		policy    CatchUpPolicy
+		advance   time.Duration
+		pending   int // Armed after restore
+		want      nodeState
+	}{
+		{
+			name:      "timeout keeps original deadline",
+			restartAt: 10 * time.Second,
+			policy:    CatchUpFire,
+			advance:   20 * time.Second,
+			pending:   2,
+			want:      canceled,
+		},
+		{
+			name:      "overdue fires immediately",
+			restartAt: time.Minute,
+			policy:    CatchUpFire,
+			advance:   0,
+			pending:   2,
+			want:      canceled,
+		},
+		{
+			name:      "overdue skipped",
+			restartAt: time.Minute,
+			policy:    CatchUpSkip,
+			advance:   0,
+			pending:   1, // Scheduled deliverEvent is not due yet
+			want:      paid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Copy records so that every case restarts from the same point
+			store := NewMemTimerStore[nodeState, eventVal]()
+			for _, r := range records {
+				assert.NilError(t, store.Save(r))
+			}
+
+			clock := NewFakeClock(t0.Add(tt.restartAt))
+			after, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, paid)
+			after.SetClock(clock)
+			after.SetTimerStore(store)
+			assert.NilError(t, after.RestoreTimers(tt.policy))
+			assert.Equal(t, clock.Pending(), tt.pending)
+			clock.Advance(tt.advance)
+			assert.Equal(t, after.CurrState(), tt.want)
+		})
+	}
+}
+
+func TestFSM_SetTimerStore_Existing(t *testing.T) {
+
+	store := NewMemTimerStore[nodeState, eventVal]()
+	t0 := time.Unix(0, 0)
+
+	// Process 1 schedules payEvent in an hour
+	before, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	before.SetClock(NewFakeClock(t0))
+	before.SetTimerStore(store)
+	first := before.Schedule(payEvent, t0.Add(time.Hour))
+
+	// Process 2 schedules before restoring, without overwriting the record of process 1
+	clock := NewFakeClock(t0)
+	after, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	after.SetClock(clock)
+	after.SetTimerStore(store)
+	second := after.Schedule(readyEvent, t0.Add(2*time.Hour))
+	assert.Assert(t, second > first)
+	records, err := store.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 2)
+
+	assert.NilError(t, after.RestoreTimers(CatchUpFire))
+	clock.Advance(time.Hour)
+	assert.Equal(t, after.CurrState(), nodeState(paid))
+}
+
+// failingTimerStore Save always fails
+type failingTimerStore struct {
+	*MemTimerStore[nodeState, eventVal]
+}
+
+func (s failingTimerStore) Save(*TimerRecord[nodeState, eventVal]) error {
+	return errors.New("disk full")
+}
+
+func TestFSM_TimerStore_ErrReentrant(t *testing.T) {
+
+	f, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](timeoutFac, initial)
+	f.SetClock(NewFakeClock(time.Unix(0, 0)))
+	f.SetTimerStore(failingTimerStore{NewMemTimerStore[nodeState, eventVal]()})
+
+	// The handler calls back into FSM while the error is reported
+	var errs []error
+	f.SetOnTimerErr(func(err error) {
+		assert.Assert(t, f.OnTimerErr() != nil)
+		errs = append(errs, err)
+	})
+
+	done := make(chan uint64)
+	go func() {
+		done <- f.Schedule(payEvent, time.Unix(60, 0))
+	}()
+	select {
+	case id := <-done:
+		assert.Assert(t, f.Cancel(id))
+	case <-time.After(time.Second):
+		t.Fatal("Schedule deadlocked")
+	}
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "disk full")
+}
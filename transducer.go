@@ -0,0 +1,106 @@
+package fsm
+
+import (
+	"time"
+)
+
+// Transducer FSM producing one output of type O per transition
+// Mealy: output is a function of the edge taken. Moore: output is a function of the state entered
+type Transducer[T, S comparable, U, V, O any] struct {
+	*FSM[T, S, U, V]
+	mealy func(*Edge[T, S, U, V]) O
+	moore func(*Vertex[T, V]) O
+}
+
+// NewMealy new a Mealy transducer by desc
+// If outputs are kept in edges, (*Edge[T, S, O, V]).StoreVal can be passed as output
+func NewMealy[T, S comparable, U, V, O any](desc GraphConfig[T, S, U, V], initState T, output func(*Edge[T, S, U, V]) O) (*Transducer[T, S, U, V, O], error) {
+	f, err := NewFsm(desc, initState)
+	if err != nil {
+		return nil, err
+	}
+	return &Transducer[T, S, U, V, O]{FSM: f, mealy: output}, nil
+}
+
+// NewMoore new a Moore transducer by desc
+// If outputs are kept in vertices, (*Vertex[T, O]).StoreVal can be passed as output
+func NewMoore[T, S comparable, U, V, O any](desc GraphConfig[T, S, U, V], initState T, output func(*Vertex[T, V]) O) (*Transducer[T, S, U, V, O], error) {
+	f, err := NewFsm(desc, initState)
+	if err != nil {
+		return nil, err
+	}
+	return &Transducer[T, S, U, V, O]{FSM: f, moore: output}, nil
+}
+
+// Trigger Same as FSM.Trigger, but return the output of the transition
+// Deferred events produce zero output
+func (t *Transducer[T, S, U, V, O]) Trigger(eventVal S, args ...interface{}) (O, error) {
+	e, err := t.FSM.Trigger(eventVal, args...)
+	if err != nil {
+		var resp O
+		return resp, err
+	}
+	return t.outputOf(e), nil
+}
+
+// Run trigger events in order. Stop at the first error and return outputs produced before it
+func (t *Transducer[T, S, U, V, O]) Run(eventVals ...S) ([]O, error) {
+	resp := make([]O, 0, len(eventVals))
+	for _, ev := range eventVals {
+		out, err := t.Trigger(ev)
+		if err != nil {
+			return resp, err
+		}
+		resp = append(resp, out)
+	}
+	return resp, nil
+}
+
+// Output Output of current state for Moore, output of current edge for Mealy
+// Zero if Mealy transducer has not transited yet
+func (t *Transducer[T, S, U, V, O]) Output() (resp O) {
+	if t.moore != nil {
+		return t.moore(t.g.VertexByState(t.CurrState()))
+	}
+	if edge := t.CurrEdge(); edge != nil && t.mealy != nil {
+		return t.mealy(edge)
+	}
+	return resp
+}
+
+// outputOf Output produced by a triggered event
+func (t *Transducer[T, S, U, V, O]) outputOf(e *Event[T, S, U, V]) (resp O) {
+	if e.deferred {
+		return resp
+	}
+	if t.moore != nil {
+		return t.moore(e.ToV())
+	}
+	if t.mealy != nil {
+		return t.mealy(e.eventE)
+	}
+	return resp
+}
+
+// RunMealy feed events to g from initState without an FSM kept around, return outputs of edges taken
+// Callbacks are not involved and state timeouts never fire
+func RunMealy[T, S comparable, U, V, O any](g *Graph[T, S, U, V], initState T, output func(*Edge[T, S, U, V]) O, eventVals ...S) ([]O, error) {
+	return (&Transducer[T, S, U, V, O]{FSM: dryRun(g, initState), mealy: output}).Run(eventVals...)
+}
+
+// RunMoore feed events to g from initState without an FSM kept around, return outputs of states entered
+// Output of initState is not included. Callbacks are not involved and state timeouts never fire
+func RunMoore[T, S comparable, U, V, O any](g *Graph[T, S, U, V], initState T, output func(*Vertex[T, V]) O, eventVals ...S) ([]O, error) {
+	return (&Transducer[T, S, U, V, O]{FSM: dryRun(g, initState), moore: output}).Run(eventVals...)
+}
+
+// dryRun New a single-use FSM whose timers are armed on a clock that never advances
+func dryRun[T, S comparable, U, V any](g *Graph[T, S, U, V], initState T) *FSM[T, S, U, V] {
+	f := &FSM[T, S, U, V]{
+		g:      g,
+		noSync: true,
+		clock:  NewFakeClock(time.Time{}),
+	}
+	f.state.Store(&StateSnapshot[T, S, U, V]{Curr: initState, Regions: f.regionsOf(initState)})
+	return f
+}
@@ -0,0 +1,66 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+// turnstileFac Outputs kept as EventStoreVal and StatusValMap
+var turnstileFac = &DefConfig[string, string, string, int]{
+	DescList: []*DescCell[string, string, string, int]{
+		{EventVal: "coin", FromState: []string{"locked"}, ToState: "unlocked", EventStoreVal: "unlock"},
+		{EventVal: "coin", FromState: []string{"unlocked"}, ToState: "unlocked", EventStoreVal: "refund"},
+		{EventVal: "push", FromState: []string{"unlocked"}, ToState: "locked", EventStoreVal: "lock"},
+		{EventVal: "push", FromState: []string{"locked"}, ToState: "locked", EventStoreVal: "alarm"},
+	},
+	StatusValMap: map[string]int{"locked": 0, "unlocked": 1},
+}
+
+func TestTransducer_Mealy(t *testing.T) {
+
+	td, err := NewMealy[string, string, string, int, string](turnstileFac, "locked", (*Edge[string, string, string, int]).StoreVal)
+	assert.NilError(t, err)
+	assert.Equal(t, td.Output(), "")
+
+	out, err := td.Trigger("push")
+	assert.NilError(t, err)
+	assert.Equal(t, out, "alarm")
+
+	outs, err := td.Run("coin", "coin", "push")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, outs, []string{"unlock", "refund", "lock"})
+	assert.Equal(t, td.Output(), "lock")
+
+	// Outputs before the failing event are kept
+	outs, err = td.Run("coin", "kick")
+	assert.Assert(t, err != nil)
+	assert.DeepEqual(t, outs, []string{"unlock"})
+}
+
+func TestTransducer_Moore(t *testing.T) {
+
+	lamp := func(v *Vertex[string, int]) bool {
+		return v.StoreVal() == 1
+	}
+	td, err := NewMoore[string, string, string, int, bool](turnstileFac, "locked", lamp)
+	assert.NilError(t, err)
+	assert.Equal(t, td.Output(), false)
+
+	outs, err := td.Run("coin", "coin", "push")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, outs, []bool{true, true, false})
+}
+
+func TestRun(t *testing.T) {
+
+	g, err := turnstileFac.NewG()
+	assert.NilError(t, err)
+
+	outs, err := RunMealy(g, "locked", (*Edge[string, string, string, int]).StoreVal, "coin", "push", "push")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, outs, []string{"unlock", "lock", "alarm"})
+
+	states, err := RunMoore(g, "unlocked", (*Vertex[string, int]).StateVal, "push", "coin")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, states, []string{"locked", "unlocked"})
+}
@@ -0,0 +1,198 @@
+package fsm
+
+import (
+	"sync"
+)
+
+type (
+	// TypedFSM FSM whose events carry a payload of type P instead of untyped args
+	// Payload is passed as the only arg of the underlying FSM, so events from Send, Schedule or deferral keep it.
+	// Typed callbacks are hooks of the underlying FSM at priority 0. Guards run before any Callbacks or hooks
+	// of the phase leaving current state or running the internal action
+	TypedFSM[T, S comparable, U, V, P any] struct {
+		*FSM[T, S, U, V]
+		callbacks  *TypedCallbacks[T, S, U, V, P]
+		guards     map[S]func(*TypedEvent[T, S, U, V, P]) bool
+		guardMutex sync.RWMutex // Guard guards
+	}
+
+	// TypedCallbacks Callbacks receiving typed events
+	TypedCallbacks[T, S comparable, U, V, P any] struct {
+		onEntry           func(*TypedEvent[T, S, U, V, P]) error
+		beforeStateChange func(*TypedEvent[T, S, U, V, P]) error
+		afterStateChange  func(*TypedEvent[T, S, U, V, P]) error
+		onInternal        func(*TypedEvent[T, S, U, V, P]) error
+		onDefer           func(*TypedEvent[T, S, U, V, P], error)
+	}
+
+	// TypedEvent Event with its payload
+	TypedEvent[T, S comparable, U, V, P any] struct {
+		*Event[T, S, U, V]
+		payload P
+	}
+)
+
+// NewTypedFsm new a TypedFSM by desc
+func NewTypedFsm[T, S comparable, U, V, P any](desc GraphConfig[T, S, U, V], initState T) (*TypedFSM[T, S, U, V, P], error) {
+	g, err := desc.NewG()
+	if err != nil {
+		return nil, err
+	}
+	return NewTypedFsmByG[T, S, U, V, P](g, initState), nil
+}
+
+// NewTypedFsmByG new a TypedFSM by given graph
+func NewTypedFsmByG[T, S comparable, U, V, P any](g *Graph[T, S, U, V], initState T) *TypedFSM[T, S, U, V, P] {
+	t := &TypedFSM[T, S, U, V, P]{
+		FSM: NewFsmByG(g, initState),
+	}
+	// Guards run before Callbacks and hooks of beforeStateChange and onInternal
+	t.addGuard(func(e *Event[T, S, U, V]) error {
+		return t.guard(newTypedEvent[T, S, U, V, P](e))
+	})
+	t.AddOnEntry(func(e *Event[T, S, U, V]) error {
+		if t.callbacks != nil && t.callbacks.onEntry != nil {
+			return t.callbacks.onEntry(newTypedEvent[T, S, U, V, P](e))
+		}
+		return nil
+	}, 0)
+	t.AddBeforeStateChange(func(e *Event[T, S, U, V]) error {
+		if t.callbacks != nil && t.callbacks.beforeStateChange != nil {
+			return t.callbacks.beforeStateChange(newTypedEvent[T, S, U, V, P](e))
+		}
+		return nil
+	}, 0)
+	t.AddAfterStateChange(func(e *Event[T, S, U, V]) error {
+		if t.callbacks != nil && t.callbacks.afterStateChange != nil {
+			return t.callbacks.afterStateChange(newTypedEvent[T, S, U, V, P](e))
+		}
+		return nil
+	}, 0)
+	t.AddOnInternal(func(e *Event[T, S, U, V]) error {
+		if t.callbacks != nil && t.callbacks.onInternal != nil {
+			return t.callbacks.onInternal(newTypedEvent[T, S, U, V, P](e))
+		}
+		return nil
+	}, 0)
+	t.AddOnDefer(func(e *Event[T, S, U, V], err error) {
+		if t.callbacks != nil && t.callbacks.onDefer != nil {
+			t.callbacks.onDefer(newTypedEvent[T, S, U, V, P](e), err)
+		}
+	}, 0)
+	return t
+}
+
+// Trigger To trigger an event with payload
+func (t *TypedFSM[T, S, U, V, P]) Trigger(eventVal S, payload P) (*TypedEvent[T, S, U, V, P], error) {
+	e, err := t.FSM.Trigger(eventVal, payload)
+	if e == nil {
+		return nil, err
+	}
+	return &TypedEvent[T, S, U, V, P]{Event: e, payload: payload}, err
+}
+
+// guard Run guard of the event. Guards run once the edge is known, right before state change or internal action
+func (t *TypedFSM[T, S, U, V, P]) guard(e *TypedEvent[T, S, U, V, P]) error {
+	if guard := t.Guard(e.eventVal); guard != nil && !guard(e) {
+		return &GuardRejectedErr[T, S]{State: e.FromState(), Event: e.eventVal}
+	}
+	return nil
+}
+
+// TypedGuard Adapt a typed guard to BranchDesc.Guard of a TypedFSM
+func TypedGuard[T, S comparable, U, V, P any](guard func(*TypedEvent[T, S, U, V, P]) bool) func(*Event[T, S, U, V]) bool {
+	return func(e *Event[T, S, U, V]) bool {
+		return guard(newTypedEvent[T, S, U, V, P](e))
+	}
+}
+
+// newTypedEvent Take payload from the first arg. Zero if missing or of another type
+func newTypedEvent[T, S comparable, U, V, P any](e *Event[T, S, U, V]) *TypedEvent[T, S, U, V, P] {
+	te := &TypedEvent[T, S, U, V, P]{Event: e}
+	if len(e.args) > 0 {
+		if p, ok := e.args[0].(P); ok {
+			te.payload = p
+		}
+	}
+	return te
+}
+
+// TypedFSM Getter And Setter
+
+func (t *TypedFSM[T, S, U, V, P]) Callbacks() *TypedCallbacks[T, S, U, V, P] {
+	return t.callbacks
+}
+
+// SetCallbacks custom typed callbacks
+func (t *TypedFSM[T, S, U, V, P]) SetCallbacks(callbacks *TypedCallbacks[T, S, U, V, P]) {
+	t.callbacks = callbacks
+}
+
+func (t *TypedFSM[T, S, U, V, P]) Guard(eventVal S) func(*TypedEvent[T, S, U, V, P]) bool {
+	t.guardMutex.RLock()
+	defer t.guardMutex.RUnlock()
+	return t.guards[eventVal]
+}
+
+// SetGuard reject eventVal with GuardRejectedErr when guard returns false. Nil removes the guard
+func (t *TypedFSM[T, S, U, V, P]) SetGuard(eventVal S, guard func(*TypedEvent[T, S, U, V, P]) bool) {
+	t.guardMutex.Lock()
+	defer t.guardMutex.Unlock()
+	if guard == nil {
+		delete(t.guards, eventVal)
+		return
+	}
+	if t.guards == nil {
+		t.guards = make(map[S]func(*TypedEvent[T, S, U, V, P]) bool)
+	}
+	t.guards[eventVal] = guard
+}
+
+// TypedCallbacks Getter And Setter
+
+func (c *TypedCallbacks[T, S, U, V, P]) OnEntry() func(*TypedEvent[T, S, U, V, P]) error {
+	return c.onEntry
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) SetOnEntry(onEntry func(*TypedEvent[T, S, U, V, P]) error) {
+	c.onEntry = onEntry
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) BeforeStateChange() func(*TypedEvent[T, S, U, V, P]) error {
+	return c.beforeStateChange
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) SetBeforeStateChange(beforeStateChange func(*TypedEvent[T, S, U, V, P]) error) {
+	c.beforeStateChange = beforeStateChange
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) AfterStateChange() func(*TypedEvent[T, S, U, V, P]) error {
+	return c.afterStateChange
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) SetAfterStateChange(afterStateChange func(*TypedEvent[T, S, U, V, P]) error) {
+	c.afterStateChange = afterStateChange
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) OnInternal() func(*TypedEvent[T, S, U, V, P]) error {
+	return c.onInternal
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) SetOnInternal(onInternal func(*TypedEvent[T, S, U, V, P]) error) {
+	c.onInternal = onInternal
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) OnDefer() func(*TypedEvent[T, S, U, V, P], error) {
+	return c.onDefer
+}
+
+func (c *TypedCallbacks[T, S, U, V, P]) SetOnDefer(onDefer func(*TypedEvent[T, S, U, V, P], error)) {
+	c.onDefer = onDefer
+}
+
+// TypedEvent Getter And Setter
+
+// Payload Payload passed to Trigger
+func (e *TypedEvent[T, S, U, V, P]) Payload() P {
+	return e.payload
+}
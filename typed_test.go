@@ -0,0 +1,130 @@
+package fsm
+
+import (
+	"gotest.tools/v3/assert"
+	"math"
+	"sync"
+	"testing"
+)
+
+type payment struct {
+	Amount int
+}
+
+func TestTypedFSM(t *testing.T) {
+
+	testFSM, err := NewTypedFsm[nodeState, eventVal, edgeVal, nodeVal, payment](timeoutFac, initial)
+	assert.NilError(t, err)
+
+	var total int
+	cb := &TypedCallbacks[nodeState, eventVal, edgeVal, nodeVal, payment]{}
+	cb.SetAfterStateChange(func(e *TypedEvent[nodeState, eventVal, edgeVal, nodeVal, payment]) error {
+		total += e.Payload().Amount
+		return nil
+	})
+	testFSM.SetCallbacks(cb)
+	testFSM.SetGuard(payEvent, func(e *TypedEvent[nodeState, eventVal, edgeVal, nodeVal, payment]) bool {
+		return e.Payload().Amount > 0
+	})
+
+	_, err = testFSM.Trigger(payEvent, payment{})
+	_, ok := err.(*GuardRejectedErr[nodeState, eventVal])
+	assert.Assert(t, ok)
+	assert.Equal(t, testFSM.CurrState(), nodeState(initial))
+
+	e, err := testFSM.Trigger(payEvent, payment{Amount: 42})
+	assert.NilError(t, err)
+	assert.Equal(t, e.Payload().Amount, 42)
+	assert.Equal(t, e.ToState(), nodeState(paid))
+	assert.Equal(t, total, 42)
+
+	// Untyped triggers still work, payload is zero
+	_, err = testFSM.FSM.Trigger(deliverEvent)
+	assert.NilError(t, err)
+	assert.Equal(t, total, 42)
+}
+
+func TestTypedGuard(t *testing.T) {
+
+	type Ev = TypedEvent[string, string, NA, NA, int]
+	fac := &DefConfig[string, string, NA, NA]{
+		DescList: []*DescCell[string, string, NA, NA]{
+			{EventVal: "score", FromState: []string{"playing"}, ToState: "judge"},
+		},
+		PseudoMap: map[string]*PseudoDesc[string, string, NA, NA]{
+			"judge": {
+				Kind: VertexChoice,
+				Branches: []*BranchDesc[string, string, NA, NA]{
+					{Guard: TypedGuard(func(e *Ev) bool { return e.Payload() >= 60 }), ToState: "passed"},
+					{ToState: "failed"},
+				},
+			},
+		},
+	}
+
+	testFSM, err := NewTypedFsm[string, string, NA, NA, int](fac, "playing")
+	assert.NilError(t, err)
+	e, err := testFSM.Trigger("score", 75)
+	assert.NilError(t, err)
+	assert.Equal(t, e.ToState(), "passed")
+}
+
+func TestTypedFSM_GuardStopsHooks(t *testing.T) {
+
+	for _, policy := range []HookErrPolicy{HookStopOnFirst, HookCollectAll} {
+		testFSM, _ := NewTypedFsm[nodeState, eventVal, edgeVal, nodeVal, payment](timeoutFac, initial)
+		testFSM.SetHookErrPolicy(policy)
+		ranBefore := false
+		cb := &TypedCallbacks[nodeState, eventVal, edgeVal, nodeVal, payment]{}
+		cb.SetBeforeStateChange(func(*TypedEvent[nodeState, eventVal, edgeVal, nodeVal, payment]) error {
+			ranBefore = true
+			return nil
+		})
+		testFSM.SetCallbacks(cb)
+		legacy := &Callbacks[nodeState, eventVal, edgeVal, nodeVal]{}
+		legacy.SetBeforeStateChange(func(*Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+			ranBefore = true
+			return nil
+		})
+		testFSM.FSM.SetCallbacks(legacy)
+		testFSM.AddBeforeStateChange(func(*Event[nodeState, eventVal, edgeVal, nodeVal]) error {
+			ranBefore = true
+			return nil
+		}, math.MaxInt)
+		testFSM.SetGuard(payEvent, func(e *TypedEvent[nodeState, eventVal, edgeVal, nodeVal, payment]) bool {
+			return e.Payload().Amount > 0
+		})
+
+		_, err := testFSM.Trigger(payEvent, payment{})
+		_, ok := err.(*GuardRejectedErr[nodeState, eventVal])
+		assert.Assert(t, ok, "policy %d: %v", policy, err)
+		assert.Assert(t, !ranBefore, "policy %d", policy)
+		assert.Equal(t, testFSM.CurrState(), nodeState(initial))
+	}
+}
+
+func TestTypedFSM_SetGuard_Concurrent(t *testing.T) {
+
+	testFSM, _ := NewTypedFsm[nodeState, eventVal, edgeVal, nodeVal, payment](timeoutFac, initial)
+	allow := func(*TypedEvent[nodeState, eventVal, edgeVal, nodeVal, payment]) bool {
+		return true
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i += 1 {
+			testFSM.SetGuard(payEvent, allow)
+			testFSM.SetGuard(payEvent, nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i += 1 {
+			_, _ = testFSM.Trigger(payEvent, payment{Amount: 1})
+			testFSM.ForceSetCurrState(initial)
+		}
+	}()
+	wg.Wait()
+}
@@ -1,10 +1,27 @@
 package fsm
 
+// VertexKind Regular state or pseudo-state
+type VertexKind int
+
+const (
+	VertexState    VertexKind = iota // Regular state. FSM can stay in it
+	VertexChoice                     // Pseudo-state. Branch guards are evaluated after beforeStateChange
+	VertexJunction                   // Pseudo-state. Branch guards are evaluated before any callback
+	VertexFork                       // Parallel state. Entering it enters one state per orthogonal region
+	VertexJoin                       // Pseudo-state. Leave the fork once every region reaches its source state
+)
+
 // Vertex idx start with number 0
 type Vertex[T comparable, V any] struct {
-	idx      int // Vertex idx. Auto generated based on unique stateVal
-	stateVal T   // State value. Need to be unique
-	storeVal V   // Anything you want
+	idx      int        // Vertex idx. Auto generated based on unique stateVal
+	stateVal T          // State value. Need to be unique
+	storeVal V          // Anything you want
+	kind     VertexKind // Regular state or pseudo-state
+}
+
+// Pseudo Whether FSM passes through this vertex without staying
+func (v *Vertex[T, V]) Pseudo() bool {
+	return v.kind == VertexChoice || v.kind == VertexJunction || v.kind == VertexJoin
 }
 
 func (v *Vertex[T, V]) Idx() int {
@@ -30,3 +47,11 @@ func (v *Vertex[T, V]) StoreVal() V {
 func (v *Vertex[T, V]) SetStoreVal(storeVal V) {
 	v.storeVal = storeVal
 }
+
+func (v *Vertex[T, V]) Kind() VertexKind {
+	return v.kind
+}
+
+func (v *Vertex[T, V]) SetKind(kind VertexKind) {
+	v.kind = kind
+}
@@ -0,0 +1,70 @@
+package fsm
+
+import (
+	"context"
+)
+
+// waiter One blocking WaitFor call
+type waiter[T comparable] struct {
+	pred func(T) bool
+	ch   chan T
+}
+
+// WaitFor block until FSM enters a state that satisfies pred, or ctx is done
+// pred is called while the state is changing, so it must be fast and must not call methods of FSM
+func (f *FSM[T, S, U, V]) WaitFor(ctx context.Context, pred func(T) bool) (T, error) {
+	w := &waiter[T]{
+		pred: pred,
+		ch:   make(chan T, 1),
+	}
+
+	f.waitMutex.Lock()
+	if curr := f.CurrState(); pred(curr) {
+		f.waitMutex.Unlock()
+		return curr, nil
+	}
+	if f.waiters == nil {
+		f.waiters = make(map[*waiter[T]]struct{})
+	}
+	f.waiters[w] = struct{}{}
+	f.waitMutex.Unlock()
+
+	select {
+	case s := <-w.ch:
+		return s, nil
+	case <-ctx.Done():
+		f.waitMutex.Lock()
+		delete(f.waiters, w)
+		f.waitMutex.Unlock()
+		// Satisfied right before removal
+		select {
+		case s := <-w.ch:
+			return s, nil
+		default:
+		}
+		var resp T
+		return resp, ctx.Err()
+	}
+}
+
+// WaitForState block until FSM enters one of states, or ctx is done
+func (f *FSM[T, S, U, V]) WaitForState(ctx context.Context, states ...T) (T, error) {
+	return f.WaitFor(ctx, func(s T) bool {
+		for _, want := range states {
+			if s == want {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// notifyWaiters Wake up waiters satisfied by currState. Caller must hold waitMutex
+func (f *FSM[T, S, U, V]) notifyWaiters(currState T) {
+	for w := range f.waiters {
+		if w.pred(currState) {
+			w.ch <- currState
+			delete(f.waiters, w)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package fsm
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+func TestFSM_WaitForState(t *testing.T) {
+
+	testFSM, _ := NewFsm[nodeState, eventVal, edgeVal, nodeVal](descFac, initial)
+
+	t.Run("already in state", func(t *testing.T) {
+		s, err := testFSM.WaitForState(context.Background(), initial)
+		assert.NilError(t, err)
+		assert.Equal(t, s, nodeState(initial))
+	})
+
+	t.Run("enter state", func(t *testing.T) {
+		got := make(chan nodeState, 1)
+		go func() {
+			s, err := testFSM.WaitForState(context.Background(), done, canceled)
+			assert.Check(t, err)
+			got <- s
+		}()
+		waitRegistered(testFSM)
+		for _, e := range []eventVal{payEvent, deliverEvent, receiveEvent, readyEvent} {
+			_, err := testFSM.Trigger(e)
+			assert.NilError(t, err)
+		}
+		assert.Equal(t, <-got, nodeState(done))
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := testFSM.WaitFor(ctx, func(s nodeState) bool {
+			return s == 2333
+		})
+		assert.Equal(t, err, context.DeadlineExceeded)
+	})
+}
+
+// waitRegistered Spin until one waiter is registered
+func waitRegistered[T, S comparable, U, V any](f *FSM[T, S, U, V]) {
+	for {
+		f.waitMutex.Lock()
+		l := len(f.waiters)
+		f.waitMutex.Unlock()
+		if l > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}